@@ -6,6 +6,7 @@ import (
 	pkgutil "checker-middleware/pkg/util"
 	"checker-middleware/verify"
 	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -23,42 +24,118 @@ func CacheCheckerCMD() *cobra.Command {
 		cacheSentinels []string
 		cacheMaster    string
 		cacheTimeout   int
+
+		cacheBench             bool
+		cacheBenchDuration     int
+		cacheBenchClients      int
+		cacheBenchMix          map[string]int
+		cacheBenchPipelineSize int
+		cacheBenchValueSize    int
+		cacheBenchKeys         int
+
+		diagSlowlogCount    int
+		diagScanBudget      int
+		diagScanCount       int
+		diagScanSleep       int
+		diagBigKeyTop       int
+		diagBigKeyThreshold int64
 	)
+	newCacheConfig := func() verify.CacheConfig {
+		logger.Debug = cacheDebug
+		return verify.CacheConfig{
+			Host:      cacheHost,
+			Port:      cachePort,
+			Password:  cachePassword,
+			DB:        cacheDB,
+			Mode:      cacheMode,
+			Sentinels: cacheSentinels,
+			Master:    cacheMaster,
+			Timeout:   cacheTimeout,
+		}
+	}
 	cacheCmd := &cobra.Command{
 		Use:   "cache",
 		Short: "验证缓存可用性",
 		Run: func(cmd *cobra.Command, args []string) {
-			cfg := verify.CacheConfig{
-				Host:      cacheHost,
-				Port:      cachePort,
-				Password:  cachePassword,
-				DB:        cacheDB,
-				Mode:      cacheMode,
-				Sentinels: cacheSentinels,
-				Master:    cacheMaster,
-				Timeout:   cacheTimeout,
+			cfg := newCacheConfig()
+			if cacheBench {
+				bench := verify.CacheBenchConfig{
+					Duration:     cacheBenchDuration,
+					Clients:      cacheBenchClients,
+					Mix:          cacheBenchMix,
+					PipelineSize: cacheBenchPipelineSize,
+					ValueSize:    cacheBenchValueSize,
+					Keys:         cacheBenchKeys,
+				}
+				result := verify.BenchCacheJson(cfg, bench)
+				fmt.Println(string(result))
+				return
 			}
-			logger.Debug = cacheDebug
 			result := verify.VerifyCacheJson(cfg)
 			fmt.Println(string(result))
 		},
 	}
-	// 通用参数
-	cacheCmd.Flags().StringVarP(&cacheHost, "host", "H", "127.0.0.1", "Redis主机")
-	cacheCmd.Flags().IntVarP(&cachePort, "port", "P", 6379, "Redis端口")
-	cacheCmd.Flags().StringVarP(&cachePassword, "password", "p", "", "Redis密码")
-	cacheCmd.Flags().IntVarP(&cacheDB, "db", "d", 1, "Redis数据库")
-	cacheCmd.Flags().BoolVar(&cacheDebug, "debug", false, "Debug模式")
-	cacheCmd.Flags().IntVarP(&cacheTimeout, "timeout", "t", 10, "连接超时(秒)")
-	cacheCmd.Flags().StringVarP(&cacheMode, "mode", "m", "redis", "Redis模式: [redis|sentinel|credis]")
+	diagnoseCmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "诊断缓存性能问题(慢日志/延迟/内存/大key)",
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg := newCacheConfig()
+			diag := verify.CacheDiagnoseConfig{
+				SlowlogCount:    diagSlowlogCount,
+				ScanBudget:      diagScanBudget,
+				ScanCount:       diagScanCount,
+				ScanSleepMs:     diagScanSleep,
+				BigKeyTop:       diagBigKeyTop,
+				BigKeyThreshold: diagBigKeyThreshold,
+			}
+			result := verify.DiagnoseCacheJson(cfg, diag)
+			fmt.Println(string(result))
+		},
+	}
+	cacheCmd.AddCommand(diagnoseCmd)
+
+	for _, c := range []*cobra.Command{cacheCmd, diagnoseCmd} {
+		// 通用参数
+		c.Flags().StringVarP(&cacheHost, "host", "H", "127.0.0.1", "Redis主机")
+		c.Flags().IntVarP(&cachePort, "port", "P", 6379, "Redis端口")
+		c.Flags().StringVarP(&cachePassword, "password", "p", "", "Redis密码")
+		c.Flags().IntVarP(&cacheDB, "db", "d", 1, "Redis数据库")
+		c.Flags().BoolVar(&cacheDebug, "debug", false, "Debug模式")
+		c.Flags().IntVarP(&cacheTimeout, "timeout", "t", 10, "连接超时(秒)")
+		c.Flags().StringVarP(&cacheMode, "mode", "m", "redis", "Redis模式: [redis|sentinel|credis]")
+
+		// Sentinel专用参数
+		c.Flags().StringSliceVarP(&cacheSentinels, "sentinels", "s", []string{}, "Sentinel主机列表(多主机以,分割) 例: host1:port1,host2:port2")
+		c.Flags().StringVarP(&cacheMaster, "master", "M", "mymaster", "Sentinel主节点名称")
+	}
+
+	// 压测参数
+	cacheCmd.Flags().BoolVar(&cacheBench, "bench", false, "压测模式")
+	cacheCmd.Flags().IntVar(&cacheBenchDuration, "bench-duration", 10, "压测时长(秒)")
+	cacheCmd.Flags().IntVar(&cacheBenchClients, "bench-clients", 10, "并发客户端数")
+	cacheCmd.Flags().StringToIntVar(&cacheBenchMix, "bench-mix", map[string]int{"get": 6, "set": 3, "pipeline": 1}, "操作权重 例: get=6,set=3,pipeline=1")
+	cacheCmd.Flags().IntVar(&cacheBenchPipelineSize, "bench-pipeline-size", 10, "每个pipeline的命令数")
+	cacheCmd.Flags().IntVar(&cacheBenchValueSize, "bench-value-size", 128, "SET value大小(字节)")
+	cacheCmd.Flags().IntVar(&cacheBenchKeys, "bench-keys", 1000, "压测key数量")
+
+	// 诊断参数
+	diagnoseCmd.Flags().IntVar(&diagSlowlogCount, "slowlog-count", 10, "SLOWLOG GET 条数")
+	diagnoseCmd.Flags().IntVar(&diagScanBudget, "scan-budget", 10000, "大key采样最多扫描的key数量")
+	diagnoseCmd.Flags().IntVar(&diagScanCount, "scan-count", 100, "每次SCAN的COUNT")
+	diagnoseCmd.Flags().IntVar(&diagScanSleep, "scan-sleep", 10, "每批SCAN间隔(毫秒)")
+	diagnoseCmd.Flags().IntVar(&diagBigKeyTop, "bigkey-top", 10, "输出的大key数量")
+	diagnoseCmd.Flags().Int64Var(&diagBigKeyThreshold, "bigkey-threshold", 10*1024*1024, "大key告警阈值(字节)")
 
-	// Sentinel专用参数
-	cacheCmd.Flags().StringSliceVarP(&cacheSentinels, "sentinels", "s", []string{}, "Sentinel主机列表(多主机以,分割) 例: host1:port1,host2:port2")
-	cacheCmd.Flags().StringVarP(&cacheMaster, "master", "M", "mymaster", "Sentinel主节点名称")
 	// 自定义帮助信息
 	cacheCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
 		fmt.Println("\n用法:")
 		fmt.Printf(" %s\n\n", cmd.UseLine())
+		if cmd.HasAvailableSubCommands() {
+			fmt.Println("\n子命令:")
+			for _, sub := range cmd.Commands() {
+				fmt.Printf("     %-10s %s\n", sub.Name(), sub.Short)
+			}
+		}
 		fmt.Println("\n缓存类型:")
 		cmd.Flags().VisitAll(func(f *pflag.Flag) {
 			names := []string{"mode"}
@@ -80,6 +157,23 @@ func CacheCheckerCMD() *cobra.Command {
 				pkgutil.PrintFlag(f)
 			}
 		})
+		if cmd == cacheCmd {
+			fmt.Println("\n压测参数:")
+			cmd.Flags().VisitAll(func(f *pflag.Flag) {
+				if f.Name == "bench" || strings.HasPrefix(f.Name, "bench-") {
+					pkgutil.PrintFlag(f)
+				}
+			})
+		}
+		if cmd == diagnoseCmd {
+			fmt.Println("\n诊断参数:")
+			cmd.Flags().VisitAll(func(f *pflag.Flag) {
+				names := []string{"slowlog-count", "scan-budget", "scan-count", "scan-sleep", "bigkey-top", "bigkey-threshold"}
+				if slices.Contains(names, f.Name) {
+					pkgutil.PrintFlag(f)
+				}
+			})
+		}
 	})
 
 	return cacheCmd
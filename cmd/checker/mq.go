@@ -23,12 +23,85 @@ func MQCheckerCmd() *cobra.Command {
 		mqUser     string
 		mqPassword string
 		mqVhost    string
-		mqDebug    bool
+		mqAuth     string
+
+		mqExchange        string
+		mqExchangeType    string
+		mqExchangePassive bool
+		mqRoutingKey      string
+		mqBindingKey      string
+		mqQueueType       string
+		mqManagementURL   string
+		mqNodes           []string
+		mqDeadLetter      bool
+		mqDeadLetterTTL   int
+
+		mqNameServers   []string
+		mqProducerGroup string
+		mqConsumerGroup string
+		mqAccessKey     string
+		mqSecretKey     string
+
+		mqServiceURL string
+		mqAdminURL   string
+		mqTenant     string
+		mqNamespace  string
+		mqToken      string
+
+		mqServers          []string
+		mqJetStream        bool
+		mqStream           string
+		mqSubject          string
+		mqStreamVerifyOnly bool
+		mqNKeySeed         string
+		mqCreds            string
+		mqMQTTVersion      string
+		mqMQTTTopicPrefix  string
+		mqDestination      string
+		mqMaxMessageSize   int
+		mqDebug            bool
+
+		mqSASLMechanism string
+		mqSASLUser      string
+		mqSASLPassword  string
+		mqTLS           bool
+		mqTLSCA         string
+		mqTLSCert       string
+		mqTLSKey        string
+		mqTLSSkipVerify bool
+
+		mqTopicVerifyOnly   bool
+		mqPartitions        int
+		mqReplicationFactor int
+		mqReport            bool
+		mqGroups            []string
+		mqLagThreshold      int64
+		mqAcks              string
+		mqIdempotence       bool
+		mqTransactions      bool
+
+		mqBench            bool
+		mqBenchDuration    int
+		mqBenchProducers   int
+		mqBenchConsumers   int
+		mqBenchMessageSize int
+		mqBenchCount       int
 	)
+	rabbitmqNames := []string{"auth-mechanism", "exchange", "exchange-type", "exchange-passive", "routing-key", "binding-key", "queue-type", "management-url", "nodes", "dead-letter", "dead-letter-ttl"}
+	rocketmqNames := []string{"nameservers", "producer-group", "consumer-group", "access-key", "secret-key"}
+	pulsarNames := []string{"service-url", "admin-url", "tenant", "namespace"}
+	natsNames := []string{"jetstream", "stream", "subject", "stream-verify-only", "nkey-seed", "creds"}
+	mqttNames := []string{"mqtt-version", "mqtt-topic-prefix"}
+	activemqNames := []string{"destination", "management-url"}
+	kafkaNames := []string{"brokers", "topic", "topic-verify-only", "partitions", "replication-factor", "report", "groups", "lag-threshold", "acks", "idempotence", "transactions"}
 	mqCmd := &cobra.Command{
 		Use:   "mq",
 		Short: "验证消息队列",
 		Run: func(cmd *cobra.Command, args []string) {
+			// 指定证书或跳过校验时自动启用 TLS
+			if mqTLSCA != "" || mqTLSCert != "" || mqTLSKey != "" || mqTLSSkipVerify {
+				mqTLS = true
+			}
 			cfg := verify.MQConfig{
 				Provider: mqProvider,
 				Brokers:  strings.Split(mqBrokers, ","),
@@ -38,21 +111,165 @@ func MQCheckerCmd() *cobra.Command {
 				User:     mqUser,
 				Password: mqPassword,
 				Vhost:    mqVhost,
+
+				AuthMechanism: mqAuth,
+
+				MaxMessageSize: mqMaxMessageSize,
+
+				Exchange:        mqExchange,
+				ExchangeType:    mqExchangeType,
+				ExchangePassive: mqExchangePassive,
+				RoutingKey:      mqRoutingKey,
+				BindingKey:      mqBindingKey,
+				QueueType:       mqQueueType,
+				ManagementURL:   mqManagementURL,
+				Nodes:           mqNodes,
+				DeadLetter:      mqDeadLetter,
+				DeadLetterTTL:   mqDeadLetterTTL,
+
+				NameServers:   mqNameServers,
+				ProducerGroup: mqProducerGroup,
+				ConsumerGroup: mqConsumerGroup,
+				AccessKey:     mqAccessKey,
+				SecretKey:     mqSecretKey,
+
+				ServiceURL: mqServiceURL,
+				AdminURL:   mqAdminURL,
+				Tenant:     mqTenant,
+				Namespace:  mqNamespace,
+				Token:      mqToken,
+
+				Servers:          mqServers,
+				JetStream:        mqJetStream,
+				Stream:           mqStream,
+				Subject:          mqSubject,
+				StreamVerifyOnly: mqStreamVerifyOnly,
+				NKeySeedFile:     mqNKeySeed,
+				CredsFile:        mqCreds,
+
+				MQTTVersion:     mqMQTTVersion,
+				MQTTTopicPrefix: mqMQTTTopicPrefix,
+
+				Destination: mqDestination,
+
+				SASLMechanism: mqSASLMechanism,
+				SASLUser:      mqSASLUser,
+				SASLPassword:  mqSASLPassword,
+				TLS:           mqTLS,
+				TLSCAFile:     mqTLSCA,
+				TLSCertFile:   mqTLSCert,
+				TLSKeyFile:    mqTLSKey,
+				TLSSkipVerify: mqTLSSkipVerify,
+
+				TopicVerifyOnly:   mqTopicVerifyOnly,
+				Partitions:        mqPartitions,
+				ReplicationFactor: mqReplicationFactor,
+				Groups:            mqGroups,
+				LagThreshold:      mqLagThreshold,
+				RequiredAcks:      mqAcks,
+				Idempotence:       mqIdempotence,
+				Transactions:      mqTransactions,
 			}
 			logger.Debug = mqDebug
+			// amqps 默认端口，其他类型的端口由各自的地址参数指定
+			if provider := strings.ToLower(mqProvider); mqTLS && (provider == "rabbitmq" || provider == "mq") && !cmd.Flags().Changed("port") {
+				cfg.Port = 5671
+			}
+			if mqReport {
+				if strings.ToLower(mqProvider) != "kafka" {
+					fmt.Println("--report 仅支持 kafka")
+					return
+				}
+				fmt.Println(string(verify.KafkaClusterReportJson(cfg)))
+				return
+			}
+			if mqBench {
+				bench := verify.MQBenchConfig{
+					Duration:    mqBenchDuration,
+					Producers:   mqBenchProducers,
+					Consumers:   mqBenchConsumers,
+					MessageSize: mqBenchMessageSize,
+					Count:       mqBenchCount,
+				}
+				fmt.Println(string(verify.BenchMQJson(cfg, bench)))
+				return
+			}
+			if len(mqGroups) > 0 {
+				if strings.ToLower(mqProvider) != "kafka" {
+					fmt.Println("--groups 仅支持 kafka")
+					return
+				}
+				fmt.Println(string(verify.KafkaConsumerLagJson(cfg)))
+				return
+			}
 			result := verify.VerifyMQJson(cfg)
-			fmt.Println(result)
+			fmt.Println(string(result))
 		},
 	}
-	mqCmd.Flags().StringVarP(&mqProvider, "provider", "t", "rabbitmq", "消息队列类型[kafka/rabbitmq]")
+	mqCmd.Flags().StringVarP(&mqProvider, "provider", "t", "rabbitmq", "消息队列类型[kafka/rabbitmq/rocketmq/pulsar/nats/mqtt/activemq]")
 	mqCmd.Flags().StringVar(&mqBrokers, "brokers", "", "Kafka地址（host1:port1,host2:port2）")
-	mqCmd.Flags().StringVar(&mqTopic, "topic", "laiye_cloud", "Kafka topic")
+	mqCmd.Flags().StringVar(&mqTopic, "topic", "laiye_cloud", "Kafka/RocketMQ/Pulsar topic")
 	mqCmd.Flags().StringVarP(&mqHost, "host", "H", "", "RabbitMQ主机")
 	mqCmd.Flags().IntVarP(&mqPort, "port", "P", 5672, "RabbitMQ端口")
-	mqCmd.Flags().StringVarP(&mqUser, "user", "u", "", "RabbitMQ用户")
-	mqCmd.Flags().StringVarP(&mqPassword, "password", "p", "", "RabbitMQ密码")
+	mqCmd.Flags().StringVarP(&mqUser, "user", "u", "", "RabbitMQ/NATS/MQTT/ActiveMQ用户")
+	mqCmd.Flags().StringVarP(&mqPassword, "password", "p", "", "RabbitMQ/NATS/MQTT/ActiveMQ密码")
 	mqCmd.Flags().StringVarP(&mqVhost, "vhost", "v", "laiye_cloud", "RabbitMQ vhost")
+	mqCmd.Flags().StringVar(&mqAuth, "auth-mechanism", "plain", "RabbitMQ认证机制[plain/amqplain/external]，external需配合--tls和客户端证书")
+	mqCmd.Flags().StringVar(&mqExchange, "exchange", "", "RabbitMQ exchange，指定后通过该exchange和临时队列验证路由")
+	mqCmd.Flags().StringVar(&mqExchangeType, "exchange-type", "direct", "exchange不存在时创建的类型[direct/topic/fanout/headers]")
+	mqCmd.Flags().BoolVar(&mqExchangePassive, "exchange-passive", false, "只校验exchange是否存在，不创建")
+	mqCmd.Flags().StringVar(&mqRoutingKey, "routing-key", "", "发布消息的routing key，默认precheck.<run ID>")
+	mqCmd.Flags().StringVar(&mqBindingKey, "binding-key", "", "临时队列的binding key，默认与routing key相同")
+	mqCmd.Flags().StringVar(&mqQueueType, "queue-type", "classic", "RabbitMQ队列类型[classic/quorum/stream]")
+	mqCmd.Flags().StringVar(&mqManagementURL, "management-url", "", "管理接口地址：RabbitMQ为管理API地址，用于集群健康检查和队列策略查询，例: http://host:15672；ActiveMQ/Artemis为Jolokia地址，用于删除临时队列，例: http://host:8161/api/jolokia")
+	mqCmd.Flags().StringSliceVar(&mqNodes, "nodes", []string{}, "RabbitMQ集群节点列表(host1:port1,host2:port2)，逐个节点检查并验证跨节点收发")
+	mqCmd.Flags().BoolVar(&mqDeadLetter, "dead-letter", false, "验证死信：临时队列中的消息TTL过期以及nack(requeue=false)后进入死信队列")
+	mqCmd.Flags().IntVar(&mqDeadLetterTTL, "dead-letter-ttl", 1000, "死信检测临时队列的x-message-ttl(毫秒)")
 	mqCmd.Flags().BoolVar(&mqDebug, "debug", false, "Debug模式")
+	mqCmd.Flags().IntVar(&mqMaxMessageSize, "max-message-size", 0, "探测消息大小上限(字节)，从64KB逐次翻倍到该值并报告往返成功的最大大小，0不探测")
+	mqCmd.Flags().StringSliceVar(&mqNameServers, "nameservers", []string{}, "RocketMQ NameServer地址（host1:9876,host2:9876）")
+	mqCmd.Flags().StringVar(&mqProducerGroup, "producer-group", "precheck_producer", "RocketMQ生产者组")
+	mqCmd.Flags().StringVar(&mqConsumerGroup, "consumer-group", "", "RocketMQ消费者组，默认每次检测使用新的消费组precheck-<run ID>")
+	mqCmd.Flags().StringVar(&mqAccessKey, "access-key", "", "RocketMQ ACL AccessKey")
+	mqCmd.Flags().StringVar(&mqSecretKey, "secret-key", "", "RocketMQ ACL SecretKey")
+	mqCmd.Flags().StringVar(&mqServiceURL, "service-url", "", "Pulsar服务地址，例: pulsar://host:6650")
+	mqCmd.Flags().StringVar(&mqAdminURL, "admin-url", "", "Pulsar admin地址，例: http://host:8080")
+	mqCmd.Flags().StringVar(&mqTenant, "tenant", "public", "Pulsar租户")
+	mqCmd.Flags().StringVar(&mqNamespace, "namespace", "default", "Pulsar命名空间")
+	mqCmd.Flags().StringVar(&mqToken, "token", "", "Pulsar JWT token/NATS token")
+	mqCmd.Flags().StringSliceVar(&mqServers, "servers", []string{}, "NATS/MQTT/ActiveMQ地址（nats://host1:4222,nats://host2:4222；tcp://host:1883、ssl://host:8883；STOMP host:61613）")
+	mqCmd.Flags().BoolVar(&mqJetStream, "jetstream", false, "使用JetStream发布并通过pull consumer拉取，默认做core NATS请求/应答")
+	mqCmd.Flags().StringVar(&mqStream, "stream", "PRECHECK", "JetStream stream")
+	mqCmd.Flags().StringVar(&mqSubject, "subject", "", "NATS subject，默认precheck.<run ID>")
+	mqCmd.Flags().BoolVar(&mqStreamVerifyOnly, "stream-verify-only", false, "只校验JetStream stream是否存在，不创建")
+	mqCmd.Flags().StringVar(&mqNKeySeed, "nkey-seed", "", "NATS NKey seed文件")
+	mqCmd.Flags().StringVar(&mqCreds, "creds", "", "NATS credentials文件")
+	mqCmd.Flags().StringVar(&mqMQTTVersion, "mqtt-version", "3.1.1", "MQTT协议版本[3.1/3.1.1/5]")
+	mqCmd.Flags().StringVar(&mqMQTTTopicPrefix, "mqtt-topic-prefix", "precheck", "MQTT测试topic前缀，测试topic为<前缀>/<run ID>")
+	mqCmd.Flags().StringVar(&mqDestination, "destination", "", "ActiveMQ/Artemis STOMP目标队列，默认使用固定队列/queue/laiye_precheck，指定--management-url时使用/queue/precheck.<run ID>并在检测结束后删除")
+	mqCmd.Flags().StringVar(&mqSASLMechanism, "sasl-mechanism", "", "Kafka SASL机制[plain/scram-sha-256/scram-sha-512]")
+	mqCmd.Flags().StringVar(&mqSASLUser, "sasl-user", "", "Kafka SASL用户")
+	mqCmd.Flags().StringVar(&mqSASLPassword, "sasl-password", "", "Kafka SASL密码")
+	mqCmd.Flags().BoolVar(&mqTopicVerifyOnly, "topic-verify-only", false, "只校验Kafka topic是否存在，不创建")
+	mqCmd.Flags().IntVar(&mqPartitions, "partitions", 1, "创建Kafka topic的分区数")
+	mqCmd.Flags().IntVar(&mqReplicationFactor, "replication-factor", 1, "创建Kafka topic的副本数")
+	mqCmd.Flags().BoolVar(&mqReport, "report", false, "输出Kafka集群健康报告")
+	mqCmd.Flags().StringSliceVar(&mqGroups, "groups", []string{}, "检查Kafka消费组lag（group1,group2）")
+	mqCmd.Flags().Int64Var(&mqLagThreshold, "lag-threshold", -1, "消费组总lag告警阈值，-1不限制")
+	mqCmd.Flags().StringVar(&mqAcks, "acks", "all", "Kafka生产acks级别[all/1/0]")
+	mqCmd.Flags().BoolVar(&mqIdempotence, "idempotence", false, "检查Kafka幂等生产")
+	mqCmd.Flags().BoolVar(&mqTransactions, "transactions", false, "检查Kafka事务提交/回滚")
+	mqCmd.Flags().BoolVar(&mqBench, "bench", false, "压测模式(仅支持kafka/rabbitmq)，使用临时topic/队列并发生产消费，结束后删除")
+	mqCmd.Flags().IntVar(&mqBenchDuration, "bench-duration", 10, "压测时长(秒)")
+	mqCmd.Flags().IntVar(&mqBenchProducers, "bench-producers", 1, "并发生产者数")
+	mqCmd.Flags().IntVar(&mqBenchConsumers, "bench-consumers", 1, "并发消费者数，kafka按该值创建分区")
+	mqCmd.Flags().IntVar(&mqBenchMessageSize, "bench-message-size", 1024, "消息大小(字节)")
+	mqCmd.Flags().IntVar(&mqBenchCount, "bench-count", 0, "最多发送的消息数，0只按时长限制")
+	mqCmd.Flags().BoolVar(&mqTLS, "tls", false, "启用TLS，指定--tls-ca/--tls-cert/--tls-key/--tls-skip-verify时自动启用")
+	mqCmd.Flags().StringVar(&mqTLSCA, "tls-ca", "", "CA证书文件")
+	mqCmd.Flags().StringVar(&mqTLSCert, "tls-cert", "", "客户端证书文件")
+	mqCmd.Flags().StringVar(&mqTLSKey, "tls-key", "", "客户端私钥文件")
+	mqCmd.Flags().BoolVar(&mqTLSSkipVerify, "tls-skip-verify", false, "跳过服务端证书校验")
 
 	mqCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
 		fmt.Println("\n用法:")
@@ -66,15 +283,61 @@ func MQCheckerCmd() *cobra.Command {
 		})
 		fmt.Println("\n通用参数:")
 		cmd.Flags().VisitAll(func(f *pflag.Flag) {
-			names := []string{"brokers", "topic", "provider"}
-			if !slices.Contains(names, f.Name) {
+			if f.Name != "provider" && !slices.Contains(kafkaNames, f.Name) && !slices.Contains(rabbitmqNames, f.Name) && !slices.Contains(rocketmqNames, f.Name) && !slices.Contains(pulsarNames, f.Name) && !slices.Contains(natsNames, f.Name) && !slices.Contains(mqttNames, f.Name) && !slices.Contains(activemqNames, f.Name) && !strings.HasPrefix(f.Name, "sasl-") && !strings.HasPrefix(f.Name, "tls") && f.Name != "bench" && !strings.HasPrefix(f.Name, "bench-") {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\nTLS参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if strings.HasPrefix(f.Name, "tls") {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\n压测参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if f.Name == "bench" || strings.HasPrefix(f.Name, "bench-") {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\nRabbitMQ专用参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if slices.Contains(rabbitmqNames, f.Name) {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\nRocketMQ专用参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if slices.Contains(rocketmqNames, f.Name) {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\nPulsar专用参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if slices.Contains(pulsarNames, f.Name) {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\nNATS专用参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if slices.Contains(natsNames, f.Name) {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\nMQTT专用参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if slices.Contains(mqttNames, f.Name) {
+				pkgutil.PrintFlag(f)
+			}
+		})
+		fmt.Println("\nActiveMQ专用参数:")
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if slices.Contains(activemqNames, f.Name) {
 				pkgutil.PrintFlag(f)
 			}
 		})
 		fmt.Println("\nKafka专用参数:")
 		cmd.Flags().VisitAll(func(f *pflag.Flag) {
-			names := []string{"brokers", "topic"}
-			if slices.Contains(names, f.Name) {
+			if slices.Contains(kafkaNames, f.Name) || strings.HasPrefix(f.Name, "sasl-") {
 				pkgutil.PrintFlag(f)
 			}
 		})
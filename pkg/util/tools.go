@@ -2,10 +2,12 @@ package util
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/moby/sys/mountinfo"
 	"gopkg.in/yaml.v3"
@@ -127,3 +129,23 @@ func TrimProtocol(endpoint string) string {
 	}
 	return endpoint
 }
+
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	// 计算已排序耗时列表的百分位值，p 取值 0-100
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+func DurationMs(d time.Duration) float64 {
+	// 耗时转换为毫秒，保留三位小数
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
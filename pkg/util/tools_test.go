@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single", []time.Duration{7}, 99, 7},
+		{"p0", sorted, 0, 1},
+		{"p50", sorted, 50, 5},
+		{"p90", sorted, 90, 9},
+		{"p99", sorted, 99, 10},
+		{"p100", sorted, 100, 10},
+		{"above 100", sorted, 150, 10},
+		{"negative", sorted, -1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Percentile(tt.sorted, tt.p); got != tt.want {
+				t.Errorf("Percentile(%v, %v) = %v, want %v", tt.sorted, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationMs(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want float64
+	}{
+		{0, 0},
+		{1500 * time.Microsecond, 1.5},
+		{1234567 * time.Nanosecond, 1.235},
+		{1234499 * time.Nanosecond, 1.234},
+		{2 * time.Second, 2000},
+	}
+	for _, tt := range tests {
+		if got := DurationMs(tt.d); got != tt.want {
+			t.Errorf("DurationMs(%v) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+}
@@ -17,6 +17,9 @@ func getRedisClient(cfg CacheConfig) (redis.UniversalClient, error) {
 		Password: cfg.Password,
 		DB:       cfg.DB,
 	}
+	if cfg.PoolSize > 0 {
+		opts.PoolSize = cfg.PoolSize
+	}
 	switch cfg.Mode {
 	case "sentinel":
 		opts.Addrs = cfg.Sentinels
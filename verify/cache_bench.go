@@ -0,0 +1,258 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var cacheBenchOps = []string{"get", "set", "pipeline"}
+
+// 单个压测客户端的统计
+type cacheBenchWorker struct {
+	latencies map[string][]time.Duration
+	ops       map[string]int64
+	errors    map[string]int64
+	lastErr   error
+}
+
+// 根据耗时样本生成统计结果
+func newLatencyStats(samples []time.Duration, ops, errs int64, elapsed time.Duration) LatencyStats {
+	slices.Sort(samples)
+	stats := LatencyStats{
+		Ops:    ops,
+		Errors: errs,
+		P50Ms:  pkgutil.DurationMs(pkgutil.Percentile(samples, 50)),
+		P95Ms:  pkgutil.DurationMs(pkgutil.Percentile(samples, 95)),
+		P99Ms:  pkgutil.DurationMs(pkgutil.Percentile(samples, 99)),
+	}
+	if elapsed > 0 {
+		stats.OpsPerSec = float64(int64(float64(ops)/elapsed.Seconds()*100)) / 100
+	}
+	return stats
+}
+
+// 按权重随机选择操作
+func pickCacheBenchOp(r *rand.Rand, mix map[string]int, total int) string {
+	n := r.Intn(total)
+	for _, op := range cacheBenchOps {
+		if n < mix[op] {
+			return op
+		}
+		n -= mix[op]
+	}
+	return "get"
+}
+
+// 到达 deadline 后不再发起新请求，已发出的请求全部完成后才返回，保证清理时没有在途写入
+func runCacheBenchWorker(client redis.UniversalClient, cfg CacheConfig, bench CacheBenchConfig, prefix string, deadline time.Time, seed int64) *cacheBenchWorker {
+	w := &cacheBenchWorker{
+		latencies: map[string][]time.Duration{},
+		ops:       map[string]int64{},
+		errors:    map[string]int64{},
+	}
+	r := rand.New(rand.NewSource(seed))
+	value := strings.Repeat("x", bench.ValueSize)
+	total := 0
+	for _, op := range cacheBenchOps {
+		total += bench.Mix[op]
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	for time.Now().Before(deadline) {
+		op := pickCacheBenchOp(r, bench.Mix, total)
+		key := fmt.Sprintf("%s%d", prefix, r.Intn(bench.Keys))
+		count := int64(1)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		start := time.Now()
+		var err error
+		switch op {
+		case "get":
+			err = client.Get(ctx, key).Err()
+			if err == redis.Nil {
+				err = nil
+			}
+		case "set":
+			err = client.Set(ctx, key, value, 0).Err()
+		case "pipeline":
+			// pipeline 中 SET/GET 交替，ops 按命令数统计，耗时按往返统计
+			pipe := client.Pipeline()
+			for i := 0; i < bench.PipelineSize; i++ {
+				k := fmt.Sprintf("%s%d", prefix, r.Intn(bench.Keys))
+				if i%2 == 0 {
+					pipe.Set(ctx, k, value, 0)
+				} else {
+					pipe.Get(ctx, k)
+				}
+			}
+			_, err = pipe.Exec(ctx)
+			if err == redis.Nil {
+				err = nil
+			}
+			count = int64(bench.PipelineSize)
+		}
+		elapsed := time.Since(start)
+		cancel()
+		if err != nil {
+			w.errors[op]++
+			w.lastErr = err
+			continue
+		}
+		w.ops[op] += count
+		w.latencies[op] = append(w.latencies[op], elapsed)
+	}
+	return w
+}
+
+// 清理压测使用的 key
+func cleanupCacheBench(client redis.UniversalClient, cfg CacheConfig, prefix string, keys int) map[string]string {
+	result := map[string]string{"success": "false"}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+	batch := make([]string, 0, 500)
+	deleted := int64(0)
+	for i := 0; i < keys; i++ {
+		batch = append(batch, fmt.Sprintf("%s%d", prefix, i))
+		if len(batch) == cap(batch) || i == keys-1 {
+			n, err := client.Del(ctx, batch...).Result()
+			if err != nil {
+				result["error"] = fmt.Sprintf("del error: %v", err)
+				logger.DebugLog("bench cleanup del error: %v", err)
+				return result
+			}
+			deleted += n
+			batch = batch[:0]
+		}
+	}
+	result["success"] = "true"
+	result["deleted"] = fmt.Sprintf("%d", deleted)
+	logger.DebugLog("bench cleanup success: prefix=%s deleted=%d", prefix, deleted)
+	return result
+}
+
+func validateCacheBench(bench CacheBenchConfig) error {
+	if bench.Duration <= 0 || bench.Clients <= 0 || bench.Keys <= 0 {
+		return errors.New("duration, clients and keys must be positive")
+	}
+	if bench.ValueSize < 0 {
+		return errors.New("value size must not be negative")
+	}
+	total := 0
+	for op, weight := range bench.Mix {
+		if !slices.Contains(cacheBenchOps, op) {
+			return fmt.Errorf("unsupported bench operation: %s", op)
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight for %s", op)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return errors.New("bench mix has no operations")
+	}
+	if bench.Mix["pipeline"] > 0 && bench.PipelineSize <= 0 {
+		return errors.New("pipeline size must be positive")
+	}
+	return nil
+}
+
+// 压测
+func BenchCache(cfg CacheConfig, bench CacheBenchConfig) CacheBenchResult {
+	res := CacheBenchResult{
+		Connect: map[string]string{"success": "false"},
+		Bench:   map[string]string{"success": "skip"},
+		Cleanup: map[string]string{"success": "skip"},
+	}
+	if err := validateCacheBench(bench); err != nil {
+		res.Connect["error"] = fmt.Sprintf("bench config error: %v", err)
+		return res
+	}
+	if cfg.PoolSize < bench.Clients {
+		cfg.PoolSize = bench.Clients
+	}
+	res.Connect = CacheConnect(cfg)
+	if res.Connect["success"] != "true" {
+		return res
+	}
+	client, err := getRedisClient(cfg)
+	if err != nil {
+		res.Bench = map[string]string{"success": "false", "error": fmt.Sprintf("client error: %v", err)}
+		return res
+	}
+	defer client.Close()
+
+	prefix := fmt.Sprintf("precheck_bench:%d:", time.Now().UnixNano())
+	logger.DebugLog("BenchCache: clients=%d duration=%ds mix=%v pipeline=%d value=%dB keys=%d prefix=%s",
+		bench.Clients, bench.Duration, bench.Mix, bench.PipelineSize, bench.ValueSize, bench.Keys, prefix)
+
+	workers := make([]*cacheBenchWorker, bench.Clients)
+	var wg sync.WaitGroup
+	start := time.Now()
+	deadline := start.Add(time.Duration(bench.Duration) * time.Second)
+	for i := range workers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			workers[i] = runCacheBenchWorker(client, cfg, bench, prefix, deadline, start.UnixNano()+int64(i))
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	res.Cleanup = cleanupCacheBench(client, cfg, prefix, bench.Keys)
+
+	res.Operations = map[string]LatencyStats{}
+	var all []time.Duration
+	var totalOps, totalErrs int64
+	var lastErr error
+	for _, op := range cacheBenchOps {
+		if bench.Mix[op] == 0 {
+			continue
+		}
+		var samples []time.Duration
+		var ops, errs int64
+		for _, w := range workers {
+			samples = append(samples, w.latencies[op]...)
+			ops += w.ops[op]
+			errs += w.errors[op]
+			if w.lastErr != nil {
+				lastErr = w.lastErr
+			}
+		}
+		all = append(all, samples...)
+		totalOps += ops
+		totalErrs += errs
+		res.Operations[op] = newLatencyStats(samples, ops, errs, elapsed)
+	}
+	total := newLatencyStats(all, totalOps, totalErrs, elapsed)
+	res.Total = &total
+
+	res.Bench = map[string]string{
+		"success":  "true",
+		"duration": elapsed.Round(time.Millisecond).String(),
+		"clients":  fmt.Sprintf("%d", bench.Clients),
+	}
+	if totalOps == 0 {
+		res.Bench["success"] = "false"
+		res.Bench["error"] = "no successful operations"
+	}
+	if lastErr != nil {
+		res.Bench["last_error"] = lastErr.Error()
+	}
+	return res
+}
+
+func BenchCacheJson(cfg CacheConfig, bench CacheBenchConfig) []byte {
+	res := BenchCache(cfg, bench)
+	b, _ := json.Marshal(res)
+	return b
+}
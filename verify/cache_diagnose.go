@@ -0,0 +1,303 @@
+package verify
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 各类型获取元素个数的命令
+var bigKeyLengthCmds = map[string]string{
+	"string": "STRLEN",
+	"list":   "LLEN",
+	"set":    "SCARD",
+	"zset":   "ZCARD",
+	"hash":   "HLEN",
+	"stream": "XLEN",
+}
+
+// 慢日志
+func diagnoseSlowlog(ctx context.Context, client redis.UniversalClient, diag CacheDiagnoseConfig) (map[string]any, []string) {
+	result := map[string]any{"success": "false"}
+	logger.DebugLog("CacheDiagnose: SLOWLOG GET %d", diag.SlowlogCount)
+	// UniversalClient 未暴露 SlowLogGet，直接构造命令执行
+	cmd := redis.NewSlowLogCmd(ctx, "slowlog", "get", diag.SlowlogCount)
+	_ = client.Process(ctx, cmd)
+	logs, err := cmd.Result()
+	if err != nil {
+		result["error"] = fmt.Sprintf("slowlog error: %v", err)
+		logger.DebugLog("slowlog error: %v", err)
+		return result, nil
+	}
+	entries := make([]SlowlogEntry, 0, len(logs))
+	var slowest *SlowlogEntry
+	for _, l := range logs {
+		entry := SlowlogEntry{
+			ID:         l.ID,
+			Time:       l.Time.Format(time.RFC3339),
+			DurationMs: pkgutil.DurationMs(l.Duration),
+			Command:    pkgutil.TruncateString(strings.Join(l.Args, " "), 128, true),
+			Client:     l.ClientAddr,
+		}
+		entries = append(entries, entry)
+		if slowest == nil || entry.DurationMs > slowest.DurationMs {
+			slowest = &entries[len(entries)-1]
+		}
+	}
+	result["success"] = "true"
+	result["entries"] = entries
+	var findings []string
+	if slowest != nil {
+		findings = append(findings, fmt.Sprintf("slowlog: %d entries, slowest %.3fms: %s", len(entries), slowest.DurationMs, slowest.Command))
+	}
+	return result, findings
+}
+
+// 延迟诊断
+func diagnoseLatency(ctx context.Context, client redis.UniversalClient) (map[string]any, []string) {
+	result := map[string]any{"success": "false"}
+	logger.DebugLog("CacheDiagnose: LATENCY DOCTOR")
+	doctor, err := client.Do(ctx, "latency", "doctor").Text()
+	if err != nil {
+		result["error"] = fmt.Sprintf("latency doctor error: %v", err)
+		logger.DebugLog("latency doctor error: %v", err)
+		return result, nil
+	}
+	result["success"] = "true"
+	result["doctor"] = doctor
+	var findings []string
+	lower := strings.ToLower(doctor)
+	switch {
+	case strings.Contains(lower, "latency monitoring is disabled"):
+		findings = append(findings, "latency: latency monitor is disabled (CONFIG SET latency-monitor-threshold <ms> to enable)")
+	case !strings.Contains(lower, "no latency spike"):
+		findings = append(findings, "latency: LATENCY DOCTOR reported latency spikes, see latency.doctor")
+	}
+
+	// LATENCY LATEST: event, timestamp, latest ms, max ms
+	latest, err := client.Do(ctx, "latency", "latest").Slice()
+	if err != nil {
+		logger.DebugLog("latency latest error: %v", err)
+		return result, findings
+	}
+	events := map[string]map[string]int64{}
+	for _, item := range latest {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			continue
+		}
+		name := fmt.Sprint(fields[0])
+		events[name] = map[string]int64{
+			"latest_ms": int64(pkgutil.ToFloat64(fields[2])),
+			"max_ms":    int64(pkgutil.ToFloat64(fields[3])),
+		}
+	}
+	result["events"] = events
+	return result, findings
+}
+
+// 内存统计
+func diagnoseMemory(ctx context.Context, client redis.UniversalClient) (map[string]any, []string) {
+	result := map[string]any{"success": "false"}
+	logger.DebugLog("CacheDiagnose: MEMORY STATS")
+	stats, err := client.Do(ctx, "memory", "stats").Slice()
+	if err != nil {
+		result["error"] = fmt.Sprintf("memory stats error: %v", err)
+		logger.DebugLog("memory stats error: %v", err)
+		return result, nil
+	}
+	values := map[string]any{}
+	for i := 0; i+1 < len(stats); i += 2 {
+		name := fmt.Sprint(stats[i])
+		switch v := stats[i+1].(type) {
+		case []interface{}:
+			// db.N 等嵌套结构
+			nested := map[string]any{}
+			for j := 0; j+1 < len(v); j += 2 {
+				nested[fmt.Sprint(v[j])] = v[j+1]
+			}
+			values[name] = nested
+		default:
+			values[name] = v
+		}
+	}
+	result["success"] = "true"
+	result["stats"] = values
+	var findings []string
+	if frag := pkgutil.ToFloat64(values["fragmentation"]); frag > 1.5 {
+		findings = append(findings, fmt.Sprintf("memory: fragmentation ratio %.2f is above 1.5", frag))
+	}
+	if peak, total := pkgutil.ToFloat64(values["peak.allocated"]), pkgutil.ToFloat64(values["total.allocated"]); peak > 0 && total > 0 {
+		findings = append(findings, fmt.Sprintf("memory: %.1fMB allocated, peak %.1fMB", total/1024/1024, peak/1024/1024))
+	}
+	return result, findings
+}
+
+// 基于 SCAN 采样的大key检测，最多扫描 ScanBudget 个key，每批命令受单次超时限制
+func diagnoseBigKeys(ctx context.Context, client redis.UniversalClient, diag CacheDiagnoseConfig, timeout time.Duration) (map[string]any, []string) {
+	result := map[string]any{"success": "false"}
+	logger.DebugLog("CacheDiagnose: SCAN budget=%d count=%d", diag.ScanBudget, diag.ScanCount)
+	var (
+		cursor  uint64
+		scanned int
+		keys    []BigKey
+		largest = map[string]BigKey{}
+		counts  = map[string]int{}
+	)
+	for scanned < diag.ScanBudget {
+		count := min(diag.ScanCount, diag.ScanBudget-scanned)
+		batchCtx, cancel := context.WithTimeout(ctx, timeout)
+		batch, next, err := client.Scan(batchCtx, cursor, "", int64(count)).Result()
+		if err != nil {
+			cancel()
+			result["error"] = fmt.Sprintf("scan error: %v", err)
+			logger.DebugLog("scan error: %v", err)
+			return result, nil
+		}
+		if len(batch) > diag.ScanBudget-scanned {
+			batch = batch[:diag.ScanBudget-scanned]
+		}
+		scanned += len(batch)
+
+		pipe := client.Pipeline()
+		types := make([]*redis.StatusCmd, len(batch))
+		usages := make([]*redis.IntCmd, len(batch))
+		for i, key := range batch {
+			types[i] = pipe.Type(batchCtx, key)
+			usages[i] = pipe.MemoryUsage(batchCtx, key)
+		}
+		// key 可能在两次命令之间过期，忽略单个命令的错误
+		_, _ = pipe.Exec(batchCtx)
+
+		pipe = client.Pipeline()
+		lengths := make([]*redis.Cmd, len(batch))
+		for i, key := range batch {
+			t := types[i].Val()
+			if lenCmd, ok := bigKeyLengthCmds[t]; ok {
+				lengths[i] = pipe.Do(batchCtx, lenCmd, key)
+			}
+		}
+		_, _ = pipe.Exec(batchCtx)
+		cancel()
+
+		for i, key := range batch {
+			t := types[i].Val()
+			if t == "" || t == "none" {
+				continue
+			}
+			bk := BigKey{Key: key, Type: t, Bytes: usages[i].Val()}
+			if lengths[i] != nil {
+				bk.Length, _ = lengths[i].Int64()
+			}
+			counts[t]++
+			if cur, ok := largest[t]; !ok || bk.Bytes > cur.Bytes {
+				largest[t] = bk
+			}
+			keys = append(keys, bk)
+		}
+		// 只保留 top N，避免占用过多内存
+		slices.SortFunc(keys, func(a, b BigKey) int { return cmp.Compare(b.Bytes, a.Bytes) })
+		if len(keys) > diag.BigKeyTop {
+			keys = keys[:diag.BigKeyTop]
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+		if diag.ScanSleepMs > 0 {
+			time.Sleep(time.Duration(diag.ScanSleepMs) * time.Millisecond)
+		}
+	}
+	result["success"] = "true"
+	result["scanned"] = scanned
+	result["complete"] = cursor == 0
+	result["types"] = counts
+	result["largest_by_type"] = largest
+	result["top"] = keys
+
+	var findings []string
+	for _, bk := range keys {
+		if diag.BigKeyThreshold > 0 && bk.Bytes >= diag.BigKeyThreshold {
+			findings = append(findings, fmt.Sprintf("bigkeys: %s (%s) uses %d bytes, length %d", bk.Key, bk.Type, bk.Bytes, bk.Length))
+		}
+	}
+	if cursor != 0 {
+		findings = append(findings, fmt.Sprintf("bigkeys: sampled %d keys, scan budget reached before the keyspace was fully scanned", scanned))
+	}
+	return result, findings
+}
+
+// 诊断
+func DiagnoseCache(cfg CacheConfig, diag CacheDiagnoseConfig) CacheDiagnoseResult {
+	if diag.SlowlogCount <= 0 {
+		diag.SlowlogCount = 10
+	}
+	if diag.ScanCount <= 0 {
+		diag.ScanCount = 100
+	}
+	if diag.BigKeyTop <= 0 {
+		diag.BigKeyTop = 10
+	}
+	if diag.ScanBudget <= 0 {
+		diag.ScanBudget = 10000
+	}
+	skip := map[string]any{"success": "skip"}
+	res := CacheDiagnoseResult{
+		Connect:  CacheConnect(cfg),
+		Slowlog:  skip,
+		Latency:  skip,
+		Memory:   skip,
+		BigKeys:  skip,
+		Findings: []string{},
+	}
+	if res.Connect["success"] != "true" {
+		return res
+	}
+	client, err := getRedisClient(cfg)
+	if err != nil {
+		res.Connect = map[string]string{"success": "false", "error": fmt.Sprintf("client error: %v", err)}
+		return res
+	}
+	defer client.Close()
+
+	var findings []string
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	run := func(f func(ctx context.Context) (map[string]any, []string)) map[string]any {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		section, found := f(ctx)
+		findings = append(findings, found...)
+		return section
+	}
+	res.Slowlog = run(func(ctx context.Context) (map[string]any, []string) {
+		return diagnoseSlowlog(ctx, client, diag)
+	})
+	res.Latency = run(func(ctx context.Context) (map[string]any, []string) {
+		return diagnoseLatency(ctx, client)
+	})
+	res.Memory = run(func(ctx context.Context) (map[string]any, []string) {
+		return diagnoseMemory(ctx, client)
+	})
+	// 大key扫描总耗时与预算相关，超时按批计算
+	bigKeys, found := diagnoseBigKeys(context.Background(), client, diag, timeout)
+	res.BigKeys = bigKeys
+	findings = append(findings, found...)
+	res.Findings = append(res.Findings, findings...)
+	return res
+}
+
+func DiagnoseCacheJson(cfg CacheConfig, diag CacheDiagnoseConfig) []byte {
+	res := DiagnoseCache(cfg, diag)
+	b, _ := json.Marshal(res)
+	return b
+}
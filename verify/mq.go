@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,35 +13,172 @@ import (
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
 )
 
-func ensureKafkaTopic(cfg MQConfig) error {
-	// 只取第一个broker用于创建topic
+// 根据 SASL/TLS 配置构造 Kafka Dialer，topic 管理连接、reader、writer 统一使用
+func newKafkaDialer(cfg MQConfig) (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+	switch strings.ToLower(cfg.SASLMechanism) {
+	case "":
+	case "plain":
+		dialer.SASLMechanism = plain.Mechanism{Username: cfg.SASLUser, Password: cfg.SASLPassword}
+	case "scram-sha-256", "scram-sha-512":
+		algo := scram.SHA256
+		if strings.EqualFold(cfg.SASLMechanism, "scram-sha-512") {
+			algo = scram.SHA512
+		}
+		mechanism, err := scram.Mechanism(algo, cfg.SASLUser, cfg.SASLPassword)
+		if err != nil {
+			return nil, fmt.Errorf("kafka sasl scram error: %v", err)
+		}
+		dialer.SASLMechanism = mechanism
+	default:
+		return nil, fmt.Errorf("unsupported sasl mechanism: %s", cfg.SASLMechanism)
+	}
+	if cfg.TLS {
+		tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("kafka tls error: %v", err)
+		}
+		dialer.TLS = tlsCfg
+	}
+	logger.DebugLog("newKafkaDialer: sasl=%s tls=%v", cfg.SASLMechanism, cfg.TLS)
+	return dialer, nil
+}
+
+// 连接 controller，创建/删除 topic 需要发往 controller
+func dialKafkaController(cfg MQConfig, dialer *kafka.Dialer) (*kafka.Conn, error) {
+	// 只取第一个broker用于查询controller
 	broker := cfg.Brokers[0]
-	conn, err := kafka.Dial("tcp", broker)
-	logger.DebugLog("ensureKafkaTopic: Brokers[0] %s", broker)
+	conn, err := dialer.Dial("tcp", broker)
+	logger.DebugLog("dialKafkaController: Brokers[0] %s", broker)
 	if err != nil {
-		return fmt.Errorf("kafka dial error: %v", err)
+		return nil, fmt.Errorf("kafka dial error: %v", err)
 	}
 	defer conn.Close()
 	controller, err := conn.Controller()
 	logger.DebugLog("Kafka controller: host=%s port=%d", controller.Host, controller.Port)
 	if err != nil {
-		return fmt.Errorf("kafka controller error: %v", err)
+		return nil, fmt.Errorf("kafka controller error: %v", err)
 	}
-	var controllerConn *kafka.Conn
 	controllerAddr := net.JoinHostPort(controller.Host, fmt.Sprintf("%d", controller.Port))
-	controllerConn, err = kafka.Dial("tcp", controllerAddr)
+	controllerConn, err := dialer.Dial("tcp", controllerAddr)
+	if err != nil {
+		return nil, fmt.Errorf("kafka controller dial error: %v", err)
+	}
+	return controllerConn, nil
+}
+
+// 从元数据中读取 topic 的分区信息，topic 不存在时返回空
+func readKafkaTopicPartitions(cfg MQConfig, dialer *kafka.Dialer) ([]kafka.Partition, error) {
+	conn, err := dialer.Dial("tcp", cfg.Brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("kafka dial error: %v", err)
+	}
+	defer conn.Close()
+	// 不指定 topic 读取全部元数据，避免开启 auto.create.topics.enable 时按名称查询触发自动创建
+	all, err := conn.ReadPartitions()
 	if err != nil {
-		return fmt.Errorf("kafka controller dial error: %v", err)
+		return nil, fmt.Errorf("kafka metadata error: %v", err)
+	}
+	var partitions []kafka.Partition
+	for _, p := range all {
+		if p.Topic == cfg.Topic {
+			partitions = append(partitions, p)
+		}
+	}
+	slices.SortFunc(partitions, func(a, b kafka.Partition) int { return a.ID - b.ID })
+	return partitions, nil
+}
+
+// 检查 topic 是否存在，不存在且允许创建时按配置的分区数和副本数创建
+func ensureKafkaTopic(cfg MQConfig, dialer *kafka.Dialer) ([]kafka.Partition, bool, error) {
+	partitions, err := readKafkaTopicPartitions(cfg, dialer)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(partitions) > 0 {
+		logger.DebugLog("ensureKafkaTopic: topic %s exists with %d partitions", cfg.Topic, len(partitions))
+		return partitions, false, nil
+	}
+	if cfg.TopicVerifyOnly {
+		return nil, false, fmt.Errorf("topic %s does not exist", cfg.Topic)
+	}
+	controllerConn, err := dialKafkaController(cfg, dialer)
+	if err != nil {
+		return nil, false, err
 	}
 	defer controllerConn.Close()
+	numPartitions, replicationFactor := cfg.Partitions, cfg.ReplicationFactor
+	if numPartitions <= 0 {
+		numPartitions = 1
+	}
+	if replicationFactor <= 0 {
+		replicationFactor = 1
+	}
 	topicConfigs := []kafka.TopicConfig{{
 		Topic:             cfg.Topic,
-		NumPartitions:     1,
-		ReplicationFactor: 1,
+		NumPartitions:     numPartitions,
+		ReplicationFactor: replicationFactor,
 	}}
-	return controllerConn.CreateTopics(topicConfigs...)
+	logger.DebugLog("ensureKafkaTopic: create topic %s partitions=%d replication=%d", cfg.Topic, numPartitions, replicationFactor)
+	if err := controllerConn.CreateTopics(topicConfigs...); err != nil {
+		return nil, false, err
+	}
+	// 新建 topic 的元数据需要一段时间才能同步到所有 broker
+	for i := 0; i < 10; i++ {
+		partitions, err = readKafkaTopicPartitions(cfg, dialer)
+		if err == nil && len(partitions) == numPartitions {
+			return partitions, true, nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	// topic 已创建，返回 created 以便清理
+	if err != nil {
+		return nil, true, fmt.Errorf("topic %s created but metadata not available: %v", cfg.Topic, err)
+	}
+	return nil, true, fmt.Errorf("topic %s created but metadata shows %d/%d partitions", cfg.Topic, len(partitions), numPartitions)
+}
+
+// 删除本工具创建的 topic
+func deleteKafkaTopic(cfg MQConfig, dialer *kafka.Dialer) error {
+	controllerConn, err := dialKafkaController(cfg, dialer)
+	if err != nil {
+		return err
+	}
+	defer controllerConn.Close()
+	if err := controllerConn.DeleteTopics(cfg.Topic); err != nil {
+		return fmt.Errorf("kafka delete topic error: %v", err)
+	}
+	logger.DebugLog("deleteKafkaTopic: topic %s deleted", cfg.Topic)
+	return nil
+}
+
+// 汇总 topic 的分区数、副本数和 ISR
+func describeKafkaPartitions(partitions []kafka.Partition, result map[string]string) {
+	replicationFactor := 0
+	underReplicated := 0
+	isr := make([]string, 0, len(partitions))
+	for _, p := range partitions {
+		replicationFactor = max(replicationFactor, len(p.Replicas))
+		if len(p.Isr) < len(p.Replicas) {
+			underReplicated++
+		}
+		ids := make([]string, 0, len(p.Isr))
+		for _, b := range p.Isr {
+			ids = append(ids, fmt.Sprintf("%d", b.ID))
+		}
+		isr = append(isr, fmt.Sprintf("%d:[%s]", p.ID, strings.Join(ids, ",")))
+	}
+	result["partitions"] = fmt.Sprintf("%d", len(partitions))
+	result["replication_factor"] = fmt.Sprintf("%d", replicationFactor)
+	result["isr"] = strings.Join(isr, " ")
+	result["under_replicated"] = fmt.Sprintf("%d", underReplicated)
 }
 
 func MQConnect(cfg MQConfig) map[string]string {
@@ -48,23 +186,32 @@ func MQConnect(cfg MQConfig) map[string]string {
 	provider := strings.ToLower(cfg.Provider)
 	switch provider {
 	case "kafka":
-		// 自动创建topic
-		if err := ensureKafkaTopic(cfg); err != nil {
-			result["error"] = fmt.Sprintf("kafka create topic error: %v", err)
+		dialer, err := newKafkaDialer(cfg)
+		if err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		// 检查topic，按需创建
+		partitions, created, err := ensureKafkaTopic(cfg, dialer)
+		result["topic_created"] = fmt.Sprintf("%v", created)
+		if err != nil {
+			result["error"] = fmt.Sprintf("kafka topic error: %v", err)
 			return result
 		}
+		describeKafkaPartitions(partitions, result)
 		r := kafka.ReaderConfig{
 			Brokers:   cfg.Brokers,
 			Topic:     cfg.Topic,
 			Partition: 0,
 			MinBytes:  1,
 			MaxBytes:  10e6,
+			Dialer:    dialer,
 		}
 		reader := kafka.NewReader(r)
 		defer reader.Close()
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		_, err := reader.ReadLag(ctx)
+		_, err = reader.ReadLag(ctx)
 		if err != nil {
 			result["error"] = fmt.Sprintf("kafka connect error: %v", err)
 			return result
@@ -72,27 +219,59 @@ func MQConnect(cfg MQConfig) map[string]string {
 		result["success"] = "true"
 	case "rabbitmq", "mq":
 		// 检查vhost是否存在，连接时带vhost
-		vhost := cfg.Vhost
-		if vhost == "" {
-			vhost = "/" // 默认vhost
-		}
-		url := fmt.Sprintf("amqp://%s:%s@%s:%d/%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, vhost)
-		logger.DebugLog("rabbitmq connect url: %s", url)
-		conn, err := amqp.Dial(url)
+		conn, err := dialRabbitMQ(cfg)
 		if err != nil {
-			result["error"] = fmt.Sprintf("rabbitmq connect error (vhost=%s): %v", vhost, err)
+			result["error"] = err.Error()
 			return result
 		}
 		defer conn.Close()
+		// 指定 exchange 时检查或声明 exchange，并绑定临时队列
+		if cfg.Exchange != "" {
+			if _, err := setupRabbitMQTopology(conn, cfg, result); err != nil {
+				result["error"] = err.Error()
+				return result
+			}
+			result["success"] = "true"
+			return result
+		}
 		ch, err := conn.Channel()
 		if err != nil {
 			result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
 			return result
 		}
 		defer ch.Close()
-		_, err = ch.QueueDeclare("laiye_precheck", true, false, false, false, nil)
-		if err != nil {
-			result["error"] = fmt.Sprintf("rabbitmq queue error: %v", err)
+		if err := declareRabbitMQQueue(ch, cfg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "rocketmq":
+		if err := rocketmqConnect(cfg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "pulsar":
+		if err := pulsarConnect(cfg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "nats":
+		if err := natsConnect(cfg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "mqtt":
+		if err := mqttConnect(cfg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "activemq":
+		if err := activemqConnect(cfg, result); err != nil {
+			result["error"] = err.Error()
 			return result
 		}
 		result["success"] = "true"
@@ -108,44 +287,59 @@ func MQWrite(cfg MQConfig, msg string) map[string]string {
 	provider := strings.ToLower(cfg.Provider)
 	switch provider {
 	case "kafka":
-		writer := kafka.NewWriter(kafka.WriterConfig{
-			Brokers: cfg.Brokers,
-			Topic:   cfg.Topic,
-		})
-		defer writer.Close()
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		dialer, err := newKafkaDialer(cfg)
+		if err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		err := writer.WriteMessages(ctx, kafka.Message{Value: []byte(msg)})
+		err = produceKafkaRunMessage(ctx, cfg, dialer, msg, result)
 		if err != nil {
 			result["error"] = fmt.Sprintf("kafka write error: %v", err)
 			return result
 		}
 		result["success"] = "true"
 	case "rabbitmq":
-		vhost := cfg.Vhost
-		if vhost == "" {
-			vhost = "/"
-		}
-		url := fmt.Sprintf("amqp://%s:%s@%s:%d/%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, vhost)
-		conn, err := amqp.Dial(url)
+		conn, err := dialRabbitMQ(cfg)
 		if err != nil {
-			result["error"] = fmt.Sprintf("rabbitmq connect error (vhost=%s): %v", vhost, err)
+			result["error"] = err.Error()
 			return result
 		}
 		defer conn.Close()
-		ch, err := conn.Channel()
-		if err != nil {
-			result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+		if err := publishRabbitMQRunMessage(conn, cfg, cfg.Exchange, rabbitmqRoutingKey(cfg), msg, result); err != nil {
+			result["error"] = err.Error()
 			return result
 		}
-		defer ch.Close()
-		err = ch.Publish("", "laiye_precheck", false, false, amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(msg),
-		})
-		logger.DebugLog("rabbitmq write msg: %s", msg)
-		if err != nil {
-			result["error"] = fmt.Sprintf("rabbitmq write error: %v", err)
+		result["success"] = "true"
+	case "rocketmq":
+		if err := rocketmqWrite(cfg, msg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "pulsar":
+		if err := pulsarWrite(cfg, msg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "nats":
+		if err := natsWrite(cfg, msg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "mqtt":
+		// 依次验证 QoS 0/1/2 的端到端投递
+		if err := mqttWrite(cfg, msg, result); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["success"] = "true"
+	case "activemq":
+		if err := activemqWrite(cfg, msg, result); err != nil {
+			result["error"] = err.Error()
 			return result
 		}
 		result["success"] = "true"
@@ -160,35 +354,25 @@ func MQDelete(cfg MQConfig) map[string]string {
 	provider := strings.ToLower(cfg.Provider)
 	switch provider {
 	case "kafka":
-		// Kafka 没有直接删除消息的API，这里用消费一条消息模拟“删除”
-		reader := kafka.NewReader(kafka.ReaderConfig{
-			Brokers: cfg.Brokers,
-			Topic:   cfg.Topic,
-			GroupID: "precheck-delete",
-		})
-		defer reader.Close()
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		msg, err := reader.ReadMessage(ctx)
-		logger.DebugLog("Kafka Consumed message: topic=%s partition=%d offset=%d key=%s value=%s", msg.Topic, msg.Partition, msg.Offset, string(msg.Key), string(msg.Value))
+		// Kafka 没有直接删除消息的API，这里用临时消费组消费本次写入的消息模拟“删除”
+		dialer, err := newKafkaDialer(cfg)
 		if err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		// 加入消费组需要等待 rebalance，超时时间比其他阶段长
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := consumeKafkaRunMessage(ctx, cfg, dialer, result); err != nil {
 			result["error"] = fmt.Sprintf("kafka consume(delete) error: %v", err)
 			return result
 		}
 		result["success"] = "true"
 	case "rabbitmq":
-		vhost := cfg.Vhost
-		if vhost == "" {
-			vhost = "/"
-		}
-		queueName := "laiye_precheck"
-		if queueName == "" {
-			queueName = "default"
-		}
-		url := fmt.Sprintf("amqp://%s:%s@%s:%d/%s", cfg.User, cfg.Password, cfg.Host, cfg.Port, vhost)
-		conn, err := amqp.Dial(url)
+		queueName := rabbitmqQueue(cfg)
+		conn, err := dialRabbitMQ(cfg)
 		if err != nil {
-			result["error"] = fmt.Sprintf("rabbitmq connect error: %v", err)
+			result["error"] = err.Error()
 			return result
 		}
 		defer conn.Close()
@@ -198,19 +382,38 @@ func MQDelete(cfg MQConfig) map[string]string {
 			return result
 		}
 		defer ch.Close()
+		// stream 队列的消费者必须设置 prefetch，并从头读取才能读到已写入的消息
+		var consumeArgs amqp.Table
+		if rabbitmqQueueType(cfg) == "stream" {
+			if err := ch.Qos(1, 0, false); err != nil {
+				result["error"] = fmt.Sprintf("rabbitmq qos error: %v", err)
+				return result
+			}
+			consumeArgs = amqp.Table{"x-stream-offset": "first"}
+		}
 		// 先消费一条消息（如果有）
-		msgs, err := ch.Consume(queueName, "", false, false, false, false, nil)
+		msgs, err := ch.Consume(queueName, "", false, false, false, false, consumeArgs)
 		if err == nil {
 			select {
 			case d := <-msgs:
 				_ = d.Ack(false)
 				// 消费成功，不管ack是否报错都继续
 				logger.DebugLog("rabbitmq consume success")
+				result["run_id_matched"] = fmt.Sprintf("%v", d.MessageId == cfg.RunID)
 			case <-time.After(5 * time.Second):
 				logger.DebugLog("rabbitmq no message to delete")
 				// 没有消息，继续
 			}
 		}
+		// 临时队列只通过绑定接收消息，收不到本次消息说明路由不通
+		if cfg.Exchange != "" && result["run_id_matched"] != "true" {
+			result["error"] = fmt.Sprintf("message was not routed to queue %s via exchange %s with routing key %s", queueName, cfg.Exchange, rabbitmqRoutingKey(cfg))
+			return result
+		}
+		if queueName != rabbitmqDefaultQueue && result["run_id_matched"] != "true" {
+			result["error"] = fmt.Sprintf("message was not consumed from %s queue %s", rabbitmqQueueType(cfg), queueName)
+			return result
+		}
 		// 再删除队列
 		_, err = ch.QueueDelete(
 			queueName,
@@ -223,6 +426,46 @@ func MQDelete(cfg MQConfig) map[string]string {
 			return result
 		}
 		result["success"] = "true"
+	case "rocketmq":
+		// 消费并 ack 本次写入的消息
+		if err := rocketmqConsume(cfg, result); err != nil {
+			result["error"] = fmt.Sprintf("rocketmq consume(delete) error: %v", err)
+			return result
+		}
+		result["success"] = "true"
+	case "pulsar":
+		// 通过临时订阅消费并 ack 本次写入的消息，然后取消订阅
+		if err := pulsarConsume(cfg, result); err != nil {
+			result["error"] = fmt.Sprintf("pulsar consume(delete) error: %v", err)
+			return result
+		}
+		result["success"] = "true"
+	case "nats":
+		// core NATS 不持久化消息，请求/应答在写入阶段已完成
+		if !cfg.JetStream {
+			result["success"] = "skip"
+			return result
+		}
+		// 通过临时 pull consumer 拉取并 ack 本次写入的消息，然后删除消息和 consumer
+		if err := natsConsume(cfg, result); err != nil {
+			result["error"] = fmt.Sprintf("nats consume(delete) error: %v", err)
+			return result
+		}
+		result["success"] = "true"
+	case "mqtt":
+		// MQTT 不保存普通消息，这里验证保留消息的设置和清除
+		if err := mqttRetained(cfg, result); err != nil {
+			result["error"] = fmt.Sprintf("mqtt retained error: %v", err)
+			return result
+		}
+		result["success"] = "true"
+	case "activemq":
+		// 消费并 ack 本次写入的消息，然后取消订阅
+		if err := activemqConsume(cfg, result); err != nil {
+			result["error"] = fmt.Sprintf("activemq consume(delete) error: %v", err)
+			return result
+		}
+		result["success"] = "true"
 	default:
 		result["error"] = "unsupported provider"
 	}
@@ -230,18 +473,62 @@ func MQDelete(cfg MQConfig) map[string]string {
 }
 
 func VerifyMQ(cfg MQConfig) MQResult {
-	content := "hello"
+	if cfg.RunID == "" {
+		cfg.RunID = newRunID()
+	}
+	// 未指定主机时使用第一个集群节点完成常规检测
+	if cfg.Host == "" && len(cfg.Nodes) > 0 {
+		cfg = rabbitmqNodeConfig(cfg, cfg.Nodes[0])
+	}
+	content := "hello " + cfg.RunID
 	res := MQResult{
 		Connect: MQConnect(cfg),
 		Write:   map[string]string{"success": "skip"},
 		Delete:  map[string]string{"success": "skip"},
 	}
-	res.Write = MQWrite(cfg, content)
+	if strings.ToLower(cfg.Provider) == "kafka" {
+		res.Listeners = KafkaListeners(cfg)
+		if res.Connect["success"] != "true" && res.Listeners.Success == "false" && len(res.Listeners.Mismatch) > 0 {
+			res.Connect["error"] += "; " + res.Listeners.Mismatch[0]
+		}
+	}
+	if strings.ToLower(cfg.Provider) == "rabbitmq" && cfg.ManagementURL != "" {
+		res.Management = RabbitMQManagementHealth(cfg)
+	}
+	if strings.ToLower(cfg.Provider) == "rabbitmq" && len(cfg.Nodes) > 0 {
+		res.Nodes, res.CrossNode = RabbitMQClusterCheck(cfg)
+	}
+	if strings.ToLower(cfg.Provider) == "rabbitmq" && res.Connect["success"] == "true" && cfg.ManagementURL != "" {
+		res.QueuePolicy = RabbitMQQueuePolicy(cfg)
+	}
 	if res.Connect["success"] == "true" {
 		res.Write = MQWrite(cfg, content)
+		if res.Write["success"] == "true" && strings.ToLower(cfg.Provider) == "kafka" && (cfg.Idempotence || cfg.Transactions) {
+			res.Guarantees = KafkaProducerGuarantees(cfg)
+		}
+		if res.Write["success"] == "true" && strings.ToLower(cfg.Provider) == "rabbitmq" && cfg.DeadLetter {
+			res.DeadLetter = RabbitMQDeadLetter(cfg)
+		}
 		if res.Write["success"] == "true" {
 			res.Delete = MQDelete(cfg)
 		}
+		if cfg.MaxMessageSize > 0 && res.Write["success"] == "true" && res.Delete["success"] != "false" {
+			res.MessageSize = MQMessageSizeProbe(cfg)
+		}
+	}
+	// 创建 topic 后连接失败时也要删除 topic
+	if strings.ToLower(cfg.Provider) == "kafka" && (res.Connect["success"] == "true" || res.Connect["topic_created"] == "true") {
+		res.Cleanup = cleanupKafka(cfg, res.Connect["topic_created"] == "true")
+	}
+	if strings.ToLower(cfg.Provider) == "rabbitmq" && (res.Connect["exchange"] != "" || res.Connect["queue"] != "") {
+		res.Cleanup = cleanupRabbitMQ(cfg, res.Connect["exchange_created"] == "true")
+	}
+	// 只删除本次检测的临时队列，--destination 指定的业务队列和默认的固定队列不删除
+	if strings.ToLower(cfg.Provider) == "activemq" && cfg.Destination == "" && cfg.ManagementURL != "" && res.Write["success"] != "skip" {
+		res.Cleanup = cleanupActiveMQ(cfg)
+	}
+	if strings.ToLower(cfg.Provider) == "nats" && res.Connect["stream_created"] == "true" {
+		res.Cleanup = cleanupNATS(cfg)
 	}
 	return res
 }
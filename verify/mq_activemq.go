@@ -0,0 +1,275 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/go-stomp/stomp/v3"
+)
+
+// run ID 头同时用作 JMS selector 的属性名，不能包含 "-"
+const activemqRunIDHeader = "precheck_run_id"
+
+// 未指定 --destination 时使用固定队列
+const activemqDefaultDestination = "/queue/laiye_precheck"
+
+// 指定 --management-url 时使用以 run ID 命名的临时队列并在检测结束后删除；
+// 否则无法删除队列（Classic 不会自动删除），沿用固定队列避免每次检测遗留新队列
+func activemqDestination(cfg MQConfig) string {
+	switch {
+	case cfg.Destination != "":
+		return cfg.Destination
+	case cfg.ManagementURL != "":
+		return "/queue/precheck." + cfg.RunID
+	default:
+		return activemqDefaultDestination
+	}
+}
+
+// 依次尝试配置的 STOMP 地址，--tls 时先建立 TLS 连接
+func dialActiveMQ(cfg MQConfig) (*stomp.Conn, string, error) {
+	var opts []func(*stomp.Conn) error
+	if cfg.User != "" {
+		opts = append(opts, stomp.ConnOpt.Login(cfg.User, cfg.Password))
+	}
+	var lastErr error
+	for _, addr := range cfg.Servers {
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		var netConn io.ReadWriteCloser
+		if cfg.TLS {
+			tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+			if err != nil {
+				return nil, "", err
+			}
+			c, err := tls.DialWithDialer(dialer, "tcp", addr, tlsCfg)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			netConn = c
+		} else {
+			c, err := dialer.Dial("tcp", addr)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			netConn = c
+		}
+		conn, err := stomp.Connect(netConn, opts...)
+		if err != nil {
+			netConn.Close()
+			return nil, "", fmt.Errorf("activemq stomp connect error (%s): %v", addr, err)
+		}
+		return conn, addr, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no stomp server configured")
+	}
+	return nil, "", fmt.Errorf("activemq dial error: %v", lastErr)
+}
+
+// 建立 STOMP 会话，输出 broker 信息和协商的协议版本
+func activemqConnect(cfg MQConfig, result map[string]string) error {
+	conn, addr, err := dialActiveMQ(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Disconnect()
+	result["server"] = addr
+	result["broker"] = conn.Server()
+	result["stomp_version"] = conn.Version().String()
+	result["destination"] = activemqDestination(cfg)
+	return nil
+}
+
+// 发送一条带 run ID 头的持久化消息，并等待 broker 回执
+func activemqWrite(cfg MQConfig, msg string, result map[string]string) error {
+	conn, _, err := dialActiveMQ(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Disconnect()
+	dest := activemqDestination(cfg)
+	start := time.Now()
+	// Artemis 自动创建的地址默认为 multicast，没有订阅时消息会被丢弃，这里显式指定 anycast；ActiveMQ Classic 忽略该头
+	err = conn.Send(dest, "text/plain", []byte(msg),
+		stomp.SendOpt.Receipt,
+		stomp.SendOpt.Header("persistent", "true"),
+		stomp.SendOpt.Header("destination-type", "ANYCAST"),
+		stomp.SendOpt.Header(activemqRunIDHeader, cfg.RunID),
+	)
+	if err != nil {
+		return fmt.Errorf("activemq send error (destination=%s): %v", dest, err)
+	}
+	result["send_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	result["destination"] = dest
+	result["run_id"] = cfg.RunID
+	logger.DebugLog("activemq sent: destination=%s run_id=%s", dest, cfg.RunID)
+	return nil
+}
+
+// 订阅队列读取本次消息并逐条 ack，结束后取消订阅
+func activemqConsume(cfg MQConfig, result map[string]string) error {
+	conn, _, err := dialActiveMQ(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Disconnect()
+	dest := activemqDestination(cfg)
+	// 通过 selector 只投递本次的消息，避免消费 --destination 指定的业务队列中的其他消息
+	sub, err := conn.Subscribe(dest, stomp.AckClientIndividual,
+		stomp.SubscribeOpt.Header("subscription-type", "ANYCAST"),
+		stomp.SubscribeOpt.Header("selector", fmt.Sprintf("%s = '%s'", activemqRunIDHeader, cfg.RunID)),
+	)
+	if err != nil {
+		return fmt.Errorf("activemq subscribe error: %v", err)
+	}
+	defer func() {
+		if err := sub.Unsubscribe(); err != nil {
+			result["unsubscribe_error"] = err.Error()
+			return
+		}
+		result["unsubscribed"] = "true"
+	}()
+	start := time.Now()
+	skipped := 0
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case m, ok := <-sub.C:
+			if !ok {
+				return fmt.Errorf("subscription closed before message consumed")
+			}
+			if m.Err != nil {
+				return fmt.Errorf("activemq receive error: %v", m.Err)
+			}
+			// broker 不支持 selector 时可能投递其他消息，不 ack，取消订阅后由 broker 重新投递
+			if m.Header.Get(activemqRunIDHeader) != cfg.RunID {
+				skipped++
+				continue
+			}
+			if err := conn.Ack(m); err != nil {
+				return fmt.Errorf("activemq ack error: %v", err)
+			}
+			result["consume_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+			result["message_id"] = m.Header.Get("message-id")
+			result["skipped"] = fmt.Sprintf("%d", skipped)
+			return nil
+		case <-timeout:
+			return fmt.Errorf("run id %s not consumed from %s within 10s after skipping %d messages", cfg.RunID, dest, skipped)
+		}
+	}
+}
+
+type activemqJolokiaResponse struct {
+	Status int             `json:"status"`
+	Value  json.RawMessage `json:"value"`
+	Error  string          `json:"error"`
+}
+
+// 调用 Jolokia 接口，--management-url 为完整的 Jolokia 地址
+func activemqJolokia(client *http.Client, cfg MQConfig, request map[string]any) (json.RawMessage, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, cfg.ManagementURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(cfg.User, cfg.Password)
+	req.Header.Set("Content-Type", "application/json")
+	// Jolokia 默认校验 Origin
+	req.Header.Set("Origin", cfg.ManagementURL)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jolokia %s: %d %s", request["type"], resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var res activemqJolokiaResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("jolokia response error: %v", err)
+	}
+	if res.Status != http.StatusOK {
+		return nil, fmt.Errorf("jolokia %s: %d %s", request["type"], res.Status, res.Error)
+	}
+	return res.Value, nil
+}
+
+// 查找 broker MBean，未找到时返回空字符串
+func activemqBrokerMBean(client *http.Client, cfg MQConfig, pattern string) (string, error) {
+	value, err := activemqJolokia(client, cfg, map[string]any{"type": "search", "mbean": pattern})
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	if err := json.Unmarshal(value, &names); err != nil {
+		return "", fmt.Errorf("jolokia search response error: %v", err)
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	return names[0], nil
+}
+
+// 通过 Jolokia 删除本次检测的临时队列，兼容 Artemis 和 ActiveMQ Classic
+func cleanupActiveMQ(cfg MQConfig) map[string]string {
+	result := map[string]string{"success": "false"}
+	queue := strings.TrimPrefix(activemqDestination(cfg), "/queue/")
+	result["queue"] = queue
+	client, err := newHTTPClient(cfg.ManagementURL, cfg)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	mbean, err := activemqBrokerMBean(client, cfg, "org.apache.activemq.artemis:broker=*")
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	request := map[string]any{"type": "exec", "mbean": mbean, "operation": "destroyQueue(java.lang.String,boolean,boolean)", "arguments": []any{queue, false, true}}
+	result["broker"] = "artemis"
+	if mbean == "" {
+		mbean, err = activemqBrokerMBean(client, cfg, "org.apache.activemq:type=Broker,brokerName=*")
+		if err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		if mbean == "" {
+			result["error"] = "no ActiveMQ or Artemis broker MBean found via jolokia"
+			return result
+		}
+		request = map[string]any{"type": "exec", "mbean": mbean, "operation": "removeQueue(java.lang.String)", "arguments": []any{queue}}
+		result["broker"] = "classic"
+	}
+	if _, err := activemqJolokia(client, cfg, request); err != nil {
+		// Artemis 可能已自动删除空队列
+		if strings.Contains(err.Error(), "does not exist") {
+			result["success"] = "true"
+			result["auto_deleted"] = "true"
+			return result
+		}
+		result["error"] = fmt.Sprintf("activemq queue delete error: %v", err)
+		return result
+	}
+	logger.DebugLog("activemq queue %s deleted via %s", queue, mbean)
+	result["success"] = "true"
+	return result
+}
@@ -0,0 +1,20 @@
+package verify
+
+import "testing"
+
+func TestActiveMQDestination(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  MQConfig
+		want string
+	}{
+		{"fixed queue without jolokia", MQConfig{RunID: "r1"}, activemqDefaultDestination},
+		{"per-run queue with jolokia", MQConfig{RunID: "r1", ManagementURL: "http://host:8161/api/jolokia"}, "/queue/precheck.r1"},
+		{"explicit destination", MQConfig{RunID: "r1", Destination: "/queue/orders", ManagementURL: "http://host:8161/api/jolokia"}, "/queue/orders"},
+	}
+	for _, tt := range tests {
+		if got := activemqDestination(tt.cfg); got != tt.want {
+			t.Errorf("%s: activemqDestination() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"checker-middleware/pkg/logger"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// 生产者每次领取并发送的消息数
+	mqBenchBatch = 100
+	// 生产结束后等待消费追平的最长时间
+	mqBenchDrain = 10 * time.Second
+
+	rabbitmqSentAtHeader = "x-precheck-sent-at"
+)
+
+// 单个生产者或消费者的统计，生产者记录每批的确认耗时，消费者记录每条消息的端到端延迟
+type mqBenchWorker struct {
+	latencies []time.Duration
+	messages  int64
+	bytes     int64
+	errors    int64
+	lastErr   error
+}
+
+func (w *mqBenchWorker) fail(n int, err error) {
+	w.errors += int64(n)
+	w.lastErr = err
+}
+
+// 生产者和消费者共享的计数，用于按总消息数停止生产，以及判断消费是否已追平
+type mqBenchState struct {
+	bench         MQBenchConfig
+	deadline      time.Time
+	drainDeadline time.Time
+	claimed       atomic.Int64
+	produced      atomic.Int64
+	consumed      atomic.Int64
+	done          chan struct{} // 所有生产者结束后关闭
+}
+
+// 领取下一批要发送的消息数，到达压测时长或总消息数后返回 0
+func (s *mqBenchState) claim() int {
+	if time.Now().After(s.deadline) {
+		return 0
+	}
+	if s.bench.Count <= 0 {
+		return mqBenchBatch
+	}
+	end := s.claimed.Add(mqBenchBatch)
+	remain := int64(s.bench.Count) - (end - mqBenchBatch)
+	if remain <= 0 {
+		return 0
+	}
+	return int(min(remain, mqBenchBatch))
+}
+
+// 生产结束后，消费数追平生产数或超过等待时间时消费者退出
+func (s *mqBenchState) finished() bool {
+	select {
+	case <-s.done:
+		return s.consumed.Load() >= s.produced.Load() || time.Now().After(s.drainDeadline)
+	default:
+		return false
+	}
+}
+
+func mqBenchName(cfg MQConfig) string {
+	return "precheck-bench-" + cfg.RunID
+}
+
+func runKafkaBenchProducer(cfg MQConfig, dialer *kafka.Dialer, acks kafka.RequiredAcks, payload []byte, w *mqBenchWorker, s *mqBenchState) {
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers:      cfg.Brokers,
+		Topic:        cfg.Topic,
+		Dialer:       dialer,
+		BatchSize:    mqBenchBatch,
+		BatchTimeout: 5 * time.Millisecond,
+	})
+	writer.RequiredAcks = acks
+	if n := int64(len(payload)) + 1024; n > 1048576 {
+		writer.BatchBytes = n
+	}
+	defer writer.Close()
+	for n := s.claim(); n > 0; n = s.claim() {
+		start := time.Now()
+		sentAt := []byte(strconv.FormatInt(start.UnixNano(), 10))
+		msgs := make([]kafka.Message, n)
+		for i := range msgs {
+			msgs[i] = kafka.Message{Value: payload, Headers: []kafka.Header{{Key: kafkaSentAtHeader, Value: sentAt}}}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := writer.WriteMessages(ctx, msgs...)
+		cancel()
+		if err != nil {
+			w.fail(n, err)
+			continue
+		}
+		w.latencies = append(w.latencies, time.Since(start))
+		w.messages += int64(n)
+		w.bytes += int64(n * len(payload))
+		s.produced.Add(int64(n))
+	}
+}
+
+// 每个消费者读取一个分区，不使用消费组，避免 rebalance 影响延迟
+func runKafkaBenchConsumer(cfg MQConfig, dialer *kafka.Dialer, partition, size int, w *mqBenchWorker, s *mqBenchState) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   cfg.Brokers,
+		Topic:     cfg.Topic,
+		Partition: partition,
+		Dialer:    dialer,
+		MaxBytes:  max(1e6, size*mqBenchBatch),
+		MaxWait:   100 * time.Millisecond,
+	})
+	defer reader.Close()
+	for !s.finished() {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		m, err := reader.ReadMessage(ctx)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			continue
+		}
+		if err != nil {
+			w.fail(1, err)
+			return
+		}
+		now := time.Now()
+		for _, h := range m.Headers {
+			if h.Key != kafkaSentAtHeader {
+				continue
+			}
+			if ns, err := strconv.ParseInt(string(h.Value), 10, 64); err == nil {
+				w.latencies = append(w.latencies, now.Sub(time.Unix(0, ns)))
+			}
+		}
+		w.messages++
+		w.bytes += int64(len(m.Value))
+		s.consumed.Add(1)
+	}
+}
+
+// 以 confirm 模式按批发布，整批确认后记录耗时
+func runRabbitMQBenchProducer(cfg MQConfig, queue string, payload []byte, w *mqBenchWorker, s *mqBenchState) {
+	conn, err := dialRabbitMQ(cfg)
+	if err != nil {
+		w.fail(0, err)
+		return
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		w.fail(0, fmt.Errorf("rabbitmq channel error: %v", err))
+		return
+	}
+	defer ch.Close()
+	if err := ch.Confirm(false); err != nil {
+		w.fail(0, fmt.Errorf("rabbitmq confirm mode error: %v", err))
+		return
+	}
+	for n := s.claim(); n > 0; n = s.claim() {
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		confirms := make([]*amqp.DeferredConfirmation, 0, n)
+		for i := 0; i < n; i++ {
+			dc, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, amqp.Publishing{
+				DeliveryMode: amqp.Persistent,
+				Headers:      amqp.Table{rabbitmqSentAtHeader: time.Now().UnixNano()},
+				Body:         payload,
+			})
+			if err != nil {
+				w.fail(n-i, fmt.Errorf("rabbitmq publish error: %v", err))
+				break
+			}
+			confirms = append(confirms, dc)
+		}
+		acked := 0
+		for _, dc := range confirms {
+			ack, err := dc.WaitContext(ctx)
+			if err != nil || !ack {
+				w.fail(1, fmt.Errorf("publish not confirmed: ack=%v err=%v", ack, err))
+				continue
+			}
+			acked++
+		}
+		cancel()
+		if ch.IsClosed() {
+			return
+		}
+		if acked == 0 {
+			continue
+		}
+		w.latencies = append(w.latencies, time.Since(start))
+		w.messages += int64(acked)
+		w.bytes += int64(acked * len(payload))
+		s.produced.Add(int64(acked))
+	}
+}
+
+func runRabbitMQBenchConsumer(cfg MQConfig, queue string, w *mqBenchWorker, s *mqBenchState) {
+	conn, err := dialRabbitMQ(cfg)
+	if err != nil {
+		w.fail(0, err)
+		return
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		w.fail(0, fmt.Errorf("rabbitmq channel error: %v", err))
+		return
+	}
+	defer ch.Close()
+	if err := ch.Qos(mqBenchBatch, 0, false); err != nil {
+		w.fail(0, fmt.Errorf("rabbitmq qos error: %v", err))
+		return
+	}
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		w.fail(0, fmt.Errorf("rabbitmq consume error: %v", err))
+		return
+	}
+	for !s.finished() {
+		select {
+		case d, ok := <-deliveries:
+			if !ok {
+				w.fail(0, fmt.Errorf("rabbitmq channel closed"))
+				return
+			}
+			now := time.Now()
+			_ = d.Ack(false)
+			if ns, ok := d.Headers[rabbitmqSentAtHeader].(int64); ok {
+				w.latencies = append(w.latencies, now.Sub(time.Unix(0, ns)))
+			}
+			w.messages++
+			w.bytes += int64(len(d.Body))
+			s.consumed.Add(1)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// 并发运行生产者和消费者，返回各自的统计以及生产、消费阶段的耗时
+func runMQBench(bench MQBenchConfig, produce func(*mqBenchWorker, *mqBenchState), consume func(int, *mqBenchWorker, *mqBenchState)) ([]*mqBenchWorker, []*mqBenchWorker, time.Duration, time.Duration) {
+	start := time.Now()
+	s := &mqBenchState{
+		bench:    bench,
+		deadline: start.Add(time.Duration(bench.Duration) * time.Second),
+		done:     make(chan struct{}),
+	}
+	producers := make([]*mqBenchWorker, bench.Producers)
+	consumers := make([]*mqBenchWorker, bench.Consumers)
+	var consumerWG, producerWG sync.WaitGroup
+	for i := range consumers {
+		consumers[i] = &mqBenchWorker{}
+		consumerWG.Add(1)
+		go func(i int) {
+			defer consumerWG.Done()
+			consume(i, consumers[i], s)
+		}(i)
+	}
+	for i := range producers {
+		producers[i] = &mqBenchWorker{}
+		producerWG.Add(1)
+		go func(i int) {
+			defer producerWG.Done()
+			produce(producers[i], s)
+		}(i)
+	}
+	producerWG.Wait()
+	produceElapsed := time.Since(start)
+	s.drainDeadline = time.Now().Add(mqBenchDrain)
+	close(s.done)
+	consumerWG.Wait()
+	return producers, consumers, produceElapsed, time.Since(start)
+}
+
+// 汇总生产者或消费者的统计
+func mergeMQBenchWorkers(workers []*mqBenchWorker, elapsed time.Duration) (LatencyStats, int64, error) {
+	var samples []time.Duration
+	var messages, bytes, errs int64
+	var lastErr error
+	for _, w := range workers {
+		samples = append(samples, w.latencies...)
+		messages += w.messages
+		bytes += w.bytes
+		errs += w.errors
+		if w.lastErr != nil {
+			lastErr = w.lastErr
+		}
+	}
+	return newLatencyStats(samples, messages, errs, elapsed), bytes, lastErr
+}
+
+func mqBenchMBPerSec(bytes int64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%.2f", float64(bytes)/1024/1024/elapsed.Seconds())
+}
+
+func validateMQBench(bench MQBenchConfig) error {
+	if bench.Duration <= 0 || bench.Producers <= 0 || bench.Consumers <= 0 || bench.MessageSize <= 0 {
+		return errors.New("duration, producers, consumers and message size must be positive")
+	}
+	if bench.Count < 0 {
+		return errors.New("count must not be negative")
+	}
+	return nil
+}
+
+// 准备压测使用的 topic 或队列，返回生产、消费函数以及清理函数
+func prepareMQBench(cfg MQConfig, bench MQBenchConfig, res *MQBenchResult) (func(*mqBenchWorker, *mqBenchState), func(int, *mqBenchWorker, *mqBenchState), func() map[string]string) {
+	payload := []byte(strings.Repeat("x", bench.MessageSize))
+	switch strings.ToLower(cfg.Provider) {
+	case "kafka":
+		// 压测使用独立 topic，分区数与消费者数相同，每个消费者读取一个分区
+		cfg.Topic = mqBenchName(cfg)
+		cfg.TopicVerifyOnly = false
+		cfg.Partitions = bench.Consumers
+		// 先校验配置再创建 topic，避免创建后提前返回
+		dialer, err := newKafkaDialer(cfg)
+		if err != nil {
+			res.Connect["error"] = err.Error()
+			return nil, nil, nil
+		}
+		acks, err := parseKafkaAcks(cfg.RequiredAcks)
+		if err != nil {
+			res.Connect["error"] = err.Error()
+			return nil, nil, nil
+		}
+		res.Connect = MQConnect(cfg)
+		created := res.Connect["topic_created"] == "true"
+		cleanup := func() map[string]string {
+			result := map[string]string{"success": "true"}
+			if created {
+				if err := deleteKafkaTopic(cfg, dialer); err != nil {
+					return map[string]string{"success": "false", "error": err.Error()}
+				}
+				result["topic"] = cfg.Topic
+			}
+			return result
+		}
+		if res.Connect["success"] != "true" {
+			// topic 已创建但连接检查失败时同样删除
+			if created {
+				res.Cleanup = cleanup()
+			}
+			return nil, nil, nil
+		}
+		produce := func(w *mqBenchWorker, s *mqBenchState) {
+			runKafkaBenchProducer(cfg, dialer, acks, payload, w, s)
+		}
+		consume := func(i int, w *mqBenchWorker, s *mqBenchState) {
+			runKafkaBenchConsumer(cfg, dialer, i, bench.MessageSize, w, s)
+		}
+		return produce, consume, cleanup
+	case "rabbitmq":
+		if rabbitmqQueueType(cfg) == "stream" {
+			res.Connect["error"] = "stream queues are not supported in bench mode"
+			return nil, nil, nil
+		}
+		queue := mqBenchName(cfg)
+		conn, err := dialRabbitMQ(cfg)
+		if err != nil {
+			res.Connect["error"] = err.Error()
+			return nil, nil, nil
+		}
+		defer conn.Close()
+		ch, err := conn.Channel()
+		if err != nil {
+			res.Connect["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+			return nil, nil, nil
+		}
+		defer ch.Close()
+		if err := declareRabbitMQQueueOfType(ch, cfg, queue); err != nil {
+			res.Connect["error"] = err.Error()
+			return nil, nil, nil
+		}
+		res.Connect = map[string]string{"success": "true", "queue": queue, "queue_type": rabbitmqQueueType(cfg)}
+		produce := func(w *mqBenchWorker, s *mqBenchState) {
+			runRabbitMQBenchProducer(cfg, queue, payload, w, s)
+		}
+		consume := func(_ int, w *mqBenchWorker, s *mqBenchState) {
+			runRabbitMQBenchConsumer(cfg, queue, w, s)
+		}
+		cleanup := func() map[string]string {
+			result := map[string]string{"success": "false"}
+			conn, err := dialRabbitMQ(cfg)
+			if err != nil {
+				result["error"] = err.Error()
+				return result
+			}
+			defer conn.Close()
+			ch, err := conn.Channel()
+			if err != nil {
+				result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+				return result
+			}
+			defer ch.Close()
+			purged, err := ch.QueueDelete(queue, false, false, false)
+			if err != nil {
+				result["error"] = fmt.Sprintf("rabbitmq queue delete error: %v", err)
+				return result
+			}
+			result["success"] = "true"
+			result["queue"] = queue
+			result["purged"] = fmt.Sprintf("%d", purged)
+			return result
+		}
+		return produce, consume, cleanup
+	default:
+		res.Connect["error"] = "bench only supports kafka and rabbitmq"
+		return nil, nil, nil
+	}
+}
+
+// 压测：在独立的 topic 或队列上并发生产和消费，结束后删除
+func BenchMQ(cfg MQConfig, bench MQBenchConfig) MQBenchResult {
+	res := MQBenchResult{
+		Connect: map[string]string{"success": "false"},
+		Bench:   map[string]string{"success": "skip"},
+		Cleanup: map[string]string{"success": "skip"},
+	}
+	if err := validateMQBench(bench); err != nil {
+		res.Connect["error"] = fmt.Sprintf("bench config error: %v", err)
+		return res
+	}
+	if cfg.RunID == "" {
+		cfg.RunID = newRunID()
+	}
+	produce, consume, cleanup := prepareMQBench(cfg, bench, &res)
+	if res.Connect["success"] != "true" {
+		return res
+	}
+	logger.DebugLog("BenchMQ: provider=%s producers=%d consumers=%d duration=%ds count=%d size=%dB name=%s",
+		cfg.Provider, bench.Producers, bench.Consumers, bench.Duration, bench.Count, bench.MessageSize, mqBenchName(cfg))
+
+	producers, consumers, produceElapsed, consumeElapsed := runMQBench(bench, produce, consume)
+	res.Cleanup = cleanup()
+
+	produceStats, producedBytes, produceErr := mergeMQBenchWorkers(producers, produceElapsed)
+	consumeStats, consumedBytes, consumeErr := mergeMQBenchWorkers(consumers, consumeElapsed)
+	res.Produce = &produceStats
+	res.EndToEnd = &consumeStats
+	res.Bench = map[string]string{
+		"success":            "true",
+		"duration":           consumeElapsed.Round(time.Millisecond).String(),
+		"producers":          fmt.Sprintf("%d", bench.Producers),
+		"consumers":          fmt.Sprintf("%d", bench.Consumers),
+		"message_size":       fmt.Sprintf("%d", bench.MessageSize),
+		"produced":           fmt.Sprintf("%d", produceStats.Ops),
+		"consumed":           fmt.Sprintf("%d", consumeStats.Ops),
+		"produce_mb_per_sec": mqBenchMBPerSec(producedBytes, produceElapsed),
+		"consume_mb_per_sec": mqBenchMBPerSec(consumedBytes, consumeElapsed),
+	}
+	switch {
+	case produceStats.Ops == 0:
+		res.Bench["success"] = "false"
+		res.Bench["error"] = "no messages produced"
+	case consumeStats.Ops < produceStats.Ops:
+		res.Bench["success"] = "false"
+		res.Bench["error"] = fmt.Sprintf("%d messages not consumed within %s after producers finished", produceStats.Ops-consumeStats.Ops, mqBenchDrain)
+	}
+	if produceErr != nil {
+		res.Bench["produce_last_error"] = produceErr.Error()
+	}
+	if consumeErr != nil {
+		res.Bench["consume_last_error"] = consumeErr.Error()
+	}
+	return res
+}
+
+func BenchMQJson(cfg MQConfig, bench MQBenchConfig) []byte {
+	res := BenchMQ(cfg, bench)
+	b, _ := json.Marshal(res)
+	return b
+}
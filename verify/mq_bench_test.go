@@ -0,0 +1,49 @@
+package verify
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMQBenchStateClaim(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		want  []int
+	}{
+		{"less than batch", 30, []int{30}},
+		{"equal to batch", mqBenchBatch, []int{mqBenchBatch}},
+		{"not divisible", 2*mqBenchBatch + 50, []int{mqBenchBatch, mqBenchBatch, 50}},
+		{"divisible", 3 * mqBenchBatch, []int{mqBenchBatch, mqBenchBatch, mqBenchBatch}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &mqBenchState{bench: MQBenchConfig{Count: tt.count}, deadline: time.Now().Add(time.Minute)}
+			var got []int
+			for n := s.claim(); n > 0; n = s.claim() {
+				got = append(got, n)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("claim() = %v, want %v", got, tt.want)
+			}
+			// 领取完后再次领取仍返回 0
+			if n := s.claim(); n != 0 {
+				t.Errorf("claim() after exhausted = %d, want 0", n)
+			}
+		})
+	}
+}
+
+func TestMQBenchStateClaimUnlimited(t *testing.T) {
+	s := &mqBenchState{deadline: time.Now().Add(time.Minute)}
+	for i := 0; i < 3; i++ {
+		if n := s.claim(); n != mqBenchBatch {
+			t.Fatalf("claim() = %d, want %d", n, mqBenchBatch)
+		}
+	}
+	s.deadline = time.Now().Add(-time.Second)
+	if n := s.claim(); n != 0 {
+		t.Errorf("claim() after deadline = %d, want 0", n)
+	}
+}
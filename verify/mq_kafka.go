@@ -0,0 +1,216 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	kafkaRunIDHeader  = "precheck-run-id"
+	kafkaSentAtHeader = "precheck-sent-at"
+)
+
+// 本次检测使用的临时消费组
+func kafkaPrecheckGroup(runID string) string {
+	return "precheck-" + runID
+}
+
+// 基于 Dialer 的 SASL/TLS 配置构造 Kafka 管理客户端
+func newKafkaClient(cfg MQConfig, dialer *kafka.Dialer) *kafka.Client {
+	return &kafka.Client{
+		Addr:    kafka.TCP(cfg.Brokers...),
+		Timeout: 10 * time.Second,
+		Transport: &kafka.Transport{
+			DialTimeout: dialer.Timeout,
+			SASL:        dialer.SASLMechanism,
+			TLS:         dialer.TLS,
+		},
+	}
+}
+
+// 在生产前把临时消费组的位点提交到各分区当前末尾，消费时只读取本次写入之后的消息
+func commitKafkaGroupOffsets(ctx context.Context, cfg MQConfig, dialer *kafka.Dialer, group string) error {
+	partitions, err := readKafkaTopicPartitions(cfg, dialer)
+	if err != nil {
+		return err
+	}
+	if len(partitions) == 0 {
+		return fmt.Errorf("topic %s has no partitions", cfg.Topic)
+	}
+	client := newKafkaClient(cfg, dialer)
+	requests := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, p := range partitions {
+		requests = append(requests, kafka.LastOffsetOf(p.ID))
+	}
+	offsets, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{cfg.Topic: requests},
+	})
+	if err != nil {
+		return fmt.Errorf("kafka list offsets error: %v", err)
+	}
+	commits := make([]kafka.OffsetCommit, 0, len(partitions))
+	for _, p := range offsets.Topics[cfg.Topic] {
+		if p.Error != nil {
+			return fmt.Errorf("kafka list offsets error: partition %d: %v", p.Partition, p.Error)
+		}
+		commits = append(commits, kafka.OffsetCommit{Partition: p.Partition, Offset: p.LastOffset})
+	}
+	// GenerationID 为 -1 时 broker 按独立消费者处理，无需先加入消费组
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      group,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{cfg.Topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("kafka offset commit error: %v", err)
+	}
+	for _, p := range resp.Topics[cfg.Topic] {
+		if p.Error != nil {
+			return fmt.Errorf("kafka offset commit error: partition %d: %v", p.Partition, p.Error)
+		}
+	}
+	logger.DebugLog("commitKafkaGroupOffsets: group=%s offsets=%v", group, commits)
+	return nil
+}
+
+// 生产一条带 run ID 的消息，返回分区、位点和 ack 耗时
+func produceKafkaRunMessage(ctx context.Context, cfg MQConfig, dialer *kafka.Dialer, msg string, result map[string]string) error {
+	acks, err := parseKafkaAcks(cfg.RequiredAcks)
+	if err != nil {
+		return err
+	}
+	if err := commitKafkaGroupOffsets(ctx, cfg, dialer, kafkaPrecheckGroup(cfg.RunID)); err != nil {
+		return err
+	}
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers: cfg.Brokers,
+		Topic:   cfg.Topic,
+		Dialer:  dialer,
+	})
+	// WriterConfig 中 RequiredAcks 为 0 时会被当作 all，需在构造后设置
+	writer.RequiredAcks = acks
+	// 默认 BatchBytes 为 1MB，更大的消息会在客户端直接报错，这里放宽到消息大小，由 broker 的 message.max.bytes 判断
+	if n := int64(len(msg)) + 1024; n > 1048576 {
+		writer.BatchBytes = n
+	}
+	var written kafka.Message
+	writer.Completion = func(messages []kafka.Message, err error) {
+		if err == nil && len(messages) > 0 {
+			written = messages[0]
+		}
+	}
+	defer writer.Close()
+	start := time.Now()
+	err = writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(cfg.RunID),
+		Value: []byte(msg),
+		Headers: []kafka.Header{
+			{Key: kafkaRunIDHeader, Value: []byte(cfg.RunID)},
+			{Key: kafkaSentAtHeader, Value: []byte(strconv.FormatInt(start.UnixNano(), 10))},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	result["ack_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	result["run_id"] = cfg.RunID
+	result["acks"] = writer.RequiredAcks.String()
+	result["partition"] = fmt.Sprintf("%d", written.Partition)
+	result["offset"] = fmt.Sprintf("%d", written.Offset)
+	logger.DebugLog("Kafka produced message: run_id=%s partition=%d offset=%d", cfg.RunID, written.Partition, written.Offset)
+	return nil
+}
+
+// 通过临时消费组读取所有分区，直到读到本次 run ID 的消息
+func consumeKafkaRunMessage(ctx context.Context, cfg MQConfig, dialer *kafka.Dialer, result map[string]string) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  cfg.Brokers,
+		Topic:    cfg.Topic,
+		GroupID:  kafkaPrecheckGroup(cfg.RunID),
+		Dialer:   dialer,
+		MaxWait:  500 * time.Millisecond,
+		MaxBytes: max(1e6, cfg.MaxMessageSize+1024),
+	})
+	defer reader.Close()
+	start := time.Now()
+	skipped := 0
+	for {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("run id %s not consumed after skipping %d messages: %v", cfg.RunID, skipped, err)
+		}
+		var runID, sentAt string
+		for _, h := range msg.Headers {
+			switch h.Key {
+			case kafkaRunIDHeader:
+				runID = string(h.Value)
+			case kafkaSentAtHeader:
+				sentAt = string(h.Value)
+			}
+		}
+		if runID != cfg.RunID {
+			skipped++
+			logger.DebugLog("Kafka skip message: partition=%d offset=%d run_id=%s", msg.Partition, msg.Offset, runID)
+			continue
+		}
+		now := time.Now()
+		result["consume_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(now.Sub(start)))
+		if ns, err := strconv.ParseInt(sentAt, 10, 64); err == nil {
+			result["e2e_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(now.Sub(time.Unix(0, ns))))
+		}
+		result["partition"] = fmt.Sprintf("%d", msg.Partition)
+		result["offset"] = fmt.Sprintf("%d", msg.Offset)
+		result["skipped"] = fmt.Sprintf("%d", skipped)
+		logger.DebugLog("Kafka Consumed message: topic=%s partition=%d offset=%d key=%s value=%s", msg.Topic, msg.Partition, msg.Offset, string(msg.Key), string(msg.Value))
+		return nil
+	}
+}
+
+// 删除临时消费组，需在 reader 关闭、成员退出后执行
+func deleteKafkaGroup(cfg MQConfig, dialer *kafka.Dialer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	group := kafkaPrecheckGroup(cfg.RunID)
+	resp, err := newKafkaClient(cfg, dialer).DeleteGroups(ctx, &kafka.DeleteGroupsRequest{GroupIDs: []string{group}})
+	if err != nil {
+		return fmt.Errorf("kafka delete group error: %v", err)
+	}
+	if err := resp.Errors[group]; err != nil {
+		return fmt.Errorf("kafka delete group %s error: %w", group, err)
+	}
+	logger.DebugLog("deleteKafkaGroup: group %s deleted", group)
+	return nil
+}
+
+// 清理临时消费组，以及本工具创建的 topic
+func cleanupKafka(cfg MQConfig, topicCreated bool) map[string]string {
+	result := map[string]string{"success": "false"}
+	dialer, err := newKafkaDialer(cfg)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	// 写入阶段在提交位点前失败时消费组不存在
+	if err := deleteKafkaGroup(cfg, dialer); err != nil && !errors.Is(err, kafka.GroupIdNotFound) {
+		result["error"] = err.Error()
+		return result
+	}
+	result["group"] = kafkaPrecheckGroup(cfg.RunID)
+	if topicCreated {
+		if err := deleteKafkaTopic(cfg, dialer); err != nil {
+			result["error"] = err.Error()
+			return result
+		}
+		result["topic"] = cfg.Topic
+	}
+	result["success"] = "true"
+	return result
+}
@@ -0,0 +1,259 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+)
+
+// 生产保障探测写入的分区
+const kafkaGuaranteePartition = 0
+
+// record batch v2 各字段相对 batch 起始位置的偏移，RawRecordSet 前还有 4 字节长度
+const (
+	kafkaBatchOffset        = 4
+	kafkaBatchCRCOffset     = kafkaBatchOffset + 17
+	kafkaBatchAttrOffset    = kafkaBatchOffset + 21
+	kafkaBatchProducerID    = kafkaBatchOffset + 43
+	kafkaBatchProducerEpoch = kafkaBatchOffset + 51
+	kafkaBatchBaseSequence  = kafkaBatchOffset + 53
+)
+
+// 解析 acks 配置
+func parseKafkaAcks(acks string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(acks) {
+	case "", "all", "-1":
+		return kafka.RequireAll, nil
+	case "1", "leader":
+		return kafka.RequireOne, nil
+	case "0", "none":
+		return kafka.RequireNone, nil
+	default:
+		return 0, fmt.Errorf("unsupported acks: %s", acks)
+	}
+}
+
+// kafka-go 的 Writer 不支持幂等和事务，这里手动编码 record batch 并写入 producer id、epoch 和 sequence
+func encodeKafkaBatch(session *kafka.ProducerSession, sequence int32, transactional bool, value string) (protocol.RawRecordSet, error) {
+	attrs := protocol.Attributes(0)
+	if transactional {
+		attrs |= protocol.Transactional
+	}
+	rs := protocol.RecordSet{
+		Version:    2,
+		Attributes: attrs,
+		Records: kafka.NewRecordReader(kafka.Record{
+			Time:  time.Now(),
+			Value: kafka.NewBytes([]byte(value)),
+		}),
+	}
+	buf := &bytes.Buffer{}
+	if _, err := rs.WriteTo(buf); err != nil {
+		return protocol.RawRecordSet{}, err
+	}
+	b := buf.Bytes()
+	binary.BigEndian.PutUint64(b[kafkaBatchProducerID:], uint64(session.ProducerID))
+	binary.BigEndian.PutUint16(b[kafkaBatchProducerEpoch:], uint16(session.ProducerEpoch))
+	binary.BigEndian.PutUint32(b[kafkaBatchBaseSequence:], uint32(sequence))
+	crc := crc32.Checksum(b[kafkaBatchAttrOffset:], crc32.MakeTable(crc32.Castagnoli))
+	binary.BigEndian.PutUint32(b[kafkaBatchCRCOffset:], crc)
+	return protocol.RawRecordSet{Reader: bytes.NewReader(b)}, nil
+}
+
+func produceKafkaBatch(ctx context.Context, client *kafka.Client, cfg MQConfig, txnID string, session *kafka.ProducerSession, sequence int32, value string) (int64, error) {
+	records, err := encodeKafkaBatch(session, sequence, txnID != "", value)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.RawProduce(ctx, &kafka.RawProduceRequest{
+		Topic:           cfg.Topic,
+		Partition:       kafkaGuaranteePartition,
+		RequiredAcks:    kafka.RequireAll,
+		TransactionalID: txnID,
+		RawRecords:      records,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Error != nil {
+		return resp.BaseOffset, resp.Error
+	}
+	return resp.BaseOffset, nil
+}
+
+func initKafkaProducer(ctx context.Context, client *kafka.Client, txnID string) (*kafka.ProducerSession, error) {
+	resp, err := client.InitProducerID(ctx, &kafka.InitProducerIDRequest{
+		TransactionalID:      txnID,
+		TransactionTimeoutMs: 60000,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Producer, nil
+}
+
+// 幂等生产：同一 sequence 重复发送时 broker 应去重
+func checkKafkaIdempotence(ctx context.Context, client *kafka.Client, cfg MQConfig, result map[string]string) error {
+	session, err := initKafkaProducer(ctx, client, "")
+	if err != nil {
+		return fmt.Errorf("init producer id error: %v", err)
+	}
+	result["producer_id"] = fmt.Sprintf("%d", session.ProducerID)
+	value := "precheck idempotence " + cfg.RunID
+	first, err := produceKafkaBatch(ctx, client, cfg, "", session, 0, value)
+	if err != nil {
+		return fmt.Errorf("idempotent produce error: %v", err)
+	}
+	second, err := produceKafkaBatch(ctx, client, cfg, "", session, 0, value)
+	switch {
+	case errors.Is(err, kafka.DuplicateSequenceNumber):
+		// 0.11 版本的 broker 对重复 batch 返回错误
+	case err != nil:
+		return fmt.Errorf("idempotent retry error: %v", err)
+	case second != first:
+		return fmt.Errorf("duplicate batch was appended at offset %d, first at %d", second, first)
+	}
+	logger.DebugLog("kafka idempotence: producer_id=%d offset=%d", session.ProducerID, first)
+	return nil
+}
+
+// 单个事务：加入分区、写入、提交或回滚
+func runKafkaTransaction(ctx context.Context, client *kafka.Client, cfg MQConfig, txnID string, session *kafka.ProducerSession, sequence int32, commit bool) (int64, error) {
+	added, err := client.AddPartitionsToTxn(ctx, &kafka.AddPartitionsToTxnRequest{
+		TransactionalID: txnID,
+		ProducerID:      session.ProducerID,
+		ProducerEpoch:   session.ProducerEpoch,
+		Topics:          map[string][]kafka.AddPartitionToTxn{cfg.Topic: {{Partition: kafkaGuaranteePartition}}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("add partitions to txn error: %v", err)
+	}
+	for _, p := range added.Topics[cfg.Topic] {
+		if p.Error != nil {
+			return 0, fmt.Errorf("add partitions to txn error: %v", p.Error)
+		}
+	}
+	offset, err := produceKafkaBatch(ctx, client, cfg, txnID, session, sequence, fmt.Sprintf("precheck txn commit=%v %s", commit, cfg.RunID))
+	if err != nil {
+		return 0, fmt.Errorf("transactional produce error: %v", err)
+	}
+	end, err := client.EndTxn(ctx, &kafka.EndTxnRequest{
+		TransactionalID: txnID,
+		ProducerID:      session.ProducerID,
+		ProducerEpoch:   session.ProducerEpoch,
+		Committed:       commit,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("end txn error: %v", err)
+	}
+	if end.Error != nil {
+		return 0, fmt.Errorf("end txn error: %v", end.Error)
+	}
+	return offset, nil
+}
+
+// 事务：提交一次、回滚一次，然后以 read_committed 读取确认事务已全部结束
+func checkKafkaTransactions(ctx context.Context, client *kafka.Client, cfg MQConfig, result map[string]string) error {
+	txnID := kafkaPrecheckGroup(cfg.RunID)
+	session, err := initKafkaProducer(ctx, client, txnID)
+	if err != nil {
+		return fmt.Errorf("init transactional producer error: %v", err)
+	}
+	committed, err := runKafkaTransaction(ctx, client, cfg, txnID, session, 0, true)
+	if err != nil {
+		result["transaction_commit"] = "false"
+		return err
+	}
+	result["transaction_commit"] = "true"
+	if _, err := runKafkaTransaction(ctx, client, cfg, txnID, session, 1, false); err != nil {
+		result["transaction_abort"] = "false"
+		return err
+	}
+	result["transaction_abort"] = "true"
+
+	// 事务标记写入是异步的，等待 LSO 追上 HW
+	var fetch *kafka.FetchResponse
+	for i := 0; i < 10; i++ {
+		fetch, err = client.Fetch(ctx, &kafka.FetchRequest{
+			Topic:          cfg.Topic,
+			Partition:      kafkaGuaranteePartition,
+			Offset:         committed,
+			MaxWait:        100 * time.Millisecond,
+			IsolationLevel: kafka.ReadCommitted,
+		})
+		if err == nil && fetch.Error == nil && fetch.LastStableOffset == fetch.HighWatermark {
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if err != nil {
+		return fmt.Errorf("read committed fetch error: %v", err)
+	}
+	if fetch.Error != nil {
+		return fmt.Errorf("read committed fetch error: %v", fetch.Error)
+	}
+	result["last_stable_offset"] = fmt.Sprintf("%d", fetch.LastStableOffset)
+	result["high_watermark"] = fmt.Sprintf("%d", fetch.HighWatermark)
+	if fetch.LastStableOffset != fetch.HighWatermark {
+		return fmt.Errorf("last stable offset %d did not reach high watermark %d", fetch.LastStableOffset, fetch.HighWatermark)
+	}
+	logger.DebugLog("kafka transactions: txn_id=%s producer_id=%d lso=%d", txnID, session.ProducerID, fetch.LastStableOffset)
+	return nil
+}
+
+// 按配置探测集群接受的生产保障级别
+func KafkaProducerGuarantees(cfg MQConfig) map[string]string {
+	result := map[string]string{
+		"success":      "false",
+		"idempotence":  "skip",
+		"transactions": "skip",
+	}
+	acks, err := parseKafkaAcks(cfg.RequiredAcks)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	result["acks"] = acks.String()
+	dialer, err := newKafkaDialer(cfg)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	client := newKafkaClient(cfg, dialer)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	failed := false
+	if cfg.Idempotence {
+		result["idempotence"] = "true"
+		if err := checkKafkaIdempotence(ctx, client, cfg, result); err != nil {
+			result["idempotence"] = "false"
+			result["idempotence_error"] = err.Error()
+			failed = true
+		}
+	}
+	if cfg.Transactions {
+		result["transactions"] = "true"
+		if err := checkKafkaTransactions(ctx, client, cfg, result); err != nil {
+			result["transactions"] = "false"
+			result["transactions_error"] = err.Error()
+			failed = true
+		}
+	}
+	if !failed {
+		result["success"] = "true"
+	}
+	return result
+}
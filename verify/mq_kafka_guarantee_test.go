@@ -0,0 +1,36 @@
+package verify
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestParseKafkaAcks(t *testing.T) {
+	tests := []struct {
+		acks    string
+		want    kafka.RequiredAcks
+		wantErr bool
+	}{
+		{"", kafka.RequireAll, false},
+		{"all", kafka.RequireAll, false},
+		{"ALL", kafka.RequireAll, false},
+		{"-1", kafka.RequireAll, false},
+		{"1", kafka.RequireOne, false},
+		{"leader", kafka.RequireOne, false},
+		{"0", kafka.RequireNone, false},
+		{"none", kafka.RequireNone, false},
+		{"2", 0, true},
+		{"quorum", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseKafkaAcks(tt.acks)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseKafkaAcks(%q) error = %v, wantErr %v", tt.acks, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseKafkaAcks(%q) = %v, want %v", tt.acks, got, tt.want)
+		}
+	}
+}
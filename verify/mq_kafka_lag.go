@@ -0,0 +1,160 @@
+package verify
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"checker-middleware/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// 查询各 topic 分区的末尾位点
+func readKafkaEndOffsets(ctx context.Context, client *kafka.Client, committed map[string][]kafka.OffsetFetchPartition) (map[string]map[int]int64, error) {
+	requests := map[string][]kafka.OffsetRequest{}
+	for topic, partitions := range committed {
+		for _, p := range partitions {
+			requests[topic] = append(requests[topic], kafka.LastOffsetOf(p.Partition))
+		}
+	}
+	ends := map[string]map[int]int64{}
+	if len(requests) == 0 {
+		return ends, nil
+	}
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: requests})
+	if err != nil {
+		return nil, fmt.Errorf("kafka list offsets error: %v", err)
+	}
+	for topic, partitions := range resp.Topics {
+		ends[topic] = map[int]int64{}
+		for _, p := range partitions {
+			if p.Error != nil {
+				return nil, fmt.Errorf("kafka list offsets error: %s-%d: %v", topic, p.Partition, p.Error)
+			}
+			ends[topic][p.Partition] = p.LastOffset
+		}
+	}
+	return ends, nil
+}
+
+// 检查单个消费组的状态、成员、已提交位点和 lag
+func inspectKafkaGroup(ctx context.Context, client *kafka.Client, group string, threshold int64) KafkaGroupLag {
+	res := KafkaGroupLag{
+		Group:      group,
+		Success:    "false",
+		Members:    []KafkaGroupMember{},
+		Partitions: []KafkaPartitionLag{},
+	}
+	desc, err := client.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{group}})
+	if err != nil {
+		res.Error = fmt.Sprintf("kafka describe group error: %v", err)
+		return res
+	}
+	owners := map[string]string{}
+	for _, g := range desc.Groups {
+		if g.Error != nil {
+			res.Error = fmt.Sprintf("kafka describe group error: %v", g.Error)
+			return res
+		}
+		res.State = g.GroupState
+		for _, m := range g.Members {
+			member := KafkaGroupMember{ID: m.MemberID, ClientID: m.ClientID, Host: m.ClientHost, Assignment: []string{}}
+			for _, t := range m.MemberAssignments.Topics {
+				for _, p := range t.Partitions {
+					name := fmt.Sprintf("%s-%d", t.Topic, p)
+					member.Assignment = append(member.Assignment, name)
+					owners[name] = m.ClientID
+				}
+			}
+			res.Members = append(res.Members, member)
+		}
+	}
+	if res.State == "Dead" {
+		res.Error = fmt.Sprintf("group %s does not exist", group)
+		return res
+	}
+
+	// 不指定 topic 时返回该消费组所有已提交位点
+	offsets, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: group})
+	if err != nil {
+		res.Error = fmt.Sprintf("kafka offset fetch error: %v", err)
+		return res
+	}
+	if offsets.Error != nil {
+		res.Error = fmt.Sprintf("kafka offset fetch error: %v", offsets.Error)
+		return res
+	}
+	ends, err := readKafkaEndOffsets(ctx, client, offsets.Topics)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	for topic, partitions := range offsets.Topics {
+		for _, p := range partitions {
+			pl := KafkaPartitionLag{
+				Topic:     topic,
+				Partition: p.Partition,
+				Committed: p.CommittedOffset,
+				End:       ends[topic][p.Partition],
+				Lag:       -1,
+				Member:    owners[fmt.Sprintf("%s-%d", topic, p.Partition)],
+			}
+			if pl.Committed >= 0 {
+				pl.Lag = max(pl.End-pl.Committed, 0)
+				res.TotalLag += pl.Lag
+			}
+			res.Partitions = append(res.Partitions, pl)
+		}
+	}
+	slices.SortFunc(res.Partitions, func(a, b KafkaPartitionLag) int {
+		return cmp.Or(cmp.Compare(a.Topic, b.Topic), cmp.Compare(a.Partition, b.Partition))
+	})
+	logger.DebugLog("inspectKafkaGroup: group=%s state=%s members=%d total_lag=%d", group, res.State, len(res.Members), res.TotalLag)
+	if threshold >= 0 && res.TotalLag > threshold {
+		res.Error = fmt.Sprintf("total lag %d exceeds threshold %d", res.TotalLag, threshold)
+		return res
+	}
+	res.Success = "true"
+	return res
+}
+
+// 检查多个消费组的 lag，任一消费组失败则整体失败
+func KafkaConsumerLag(cfg MQConfig) KafkaLagResult {
+	result := KafkaLagResult{
+		Success:   "false",
+		Threshold: cfg.LagThreshold,
+		Groups:    []KafkaGroupLag{},
+	}
+	dialer, err := newKafkaDialer(cfg)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	client := newKafkaClient(cfg, dialer)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	failed := 0
+	for _, group := range cfg.Groups {
+		g := inspectKafkaGroup(ctx, client, group, cfg.LagThreshold)
+		if g.Success != "true" {
+			failed++
+		}
+		result.Groups = append(result.Groups, g)
+	}
+	if failed > 0 {
+		result.Error = fmt.Sprintf("%d of %d groups failed", failed, len(cfg.Groups))
+		return result
+	}
+	result.Success = "true"
+	return result
+}
+
+func KafkaConsumerLagJson(cfg MQConfig) []byte {
+	res := KafkaConsumerLag(cfg)
+	b, _ := json.Marshal(res)
+	return b
+}
@@ -0,0 +1,105 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// 解析并拨测单个 broker 的 advertised 地址
+func checkKafkaBroker(b kafka.Broker, controllerID int) KafkaBrokerCheck {
+	check := KafkaBrokerCheck{
+		ID:         b.ID,
+		Advertised: net.JoinHostPort(b.Host, fmt.Sprintf("%d", b.Port)),
+		Rack:       b.Rack,
+		Controller: b.ID == controllerID,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if ip := net.ParseIP(b.Host); ip != nil {
+		check.Resolved = []string{ip.String()}
+	} else {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, b.Host)
+		if err != nil {
+			check.ResolveError = err.Error()
+			logger.DebugLog("kafka broker %d resolve %s error: %v", b.ID, b.Host, err)
+			return check
+		}
+		check.Resolved = addrs
+	}
+	start := time.Now()
+	conn, err := (&net.Dialer{Timeout: 5 * time.Second}).DialContext(ctx, "tcp", check.Advertised)
+	if err != nil {
+		check.DialError = err.Error()
+		logger.DebugLog("kafka broker %d dial %s error: %v", b.ID, check.Advertised, err)
+		return check
+	}
+	check.DialMs = pkgutil.DurationMs(time.Since(start))
+	conn.Close()
+	check.Reachable = true
+	logger.DebugLog("kafka broker %d %s reachable", b.ID, check.Advertised)
+	return check
+}
+
+// 获取集群元数据，检查所有 broker 和 controller 的 advertised.listeners 是否能被当前客户端解析和连通
+func KafkaListeners(cfg MQConfig) *KafkaListenerResult {
+	result := &KafkaListenerResult{Success: "false"}
+	dialer, err := newKafkaDialer(cfg)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	// 依次尝试 bootstrap 地址，使用第一个可连通的 broker 读取元数据
+	var conn *kafka.Conn
+	for _, broker := range cfg.Brokers {
+		conn, err = dialer.Dial("tcp", broker)
+		if err == nil {
+			result.Bootstrap = broker
+			break
+		}
+		logger.DebugLog("kafka bootstrap %s dial error: %v", broker, err)
+	}
+	if conn == nil {
+		result.Error = fmt.Sprintf("no bootstrap broker reachable: %v", err)
+		return result
+	}
+	defer conn.Close()
+	brokers, err := conn.Brokers()
+	if err != nil {
+		result.Error = fmt.Sprintf("kafka metadata error: %v", err)
+		return result
+	}
+	controller, err := conn.Controller()
+	if err != nil {
+		result.Error = fmt.Sprintf("kafka controller error: %v", err)
+		return result
+	}
+	for _, b := range brokers {
+		check := checkKafkaBroker(b, controller.ID)
+		result.Brokers = append(result.Brokers, check)
+		role := "broker"
+		if check.Controller {
+			role = "controller"
+		}
+		switch {
+		case check.ResolveError != "":
+			result.Mismatch = append(result.Mismatch, fmt.Sprintf("bootstrap %s is reachable but %s %d advertises %s, which cannot be resolved from this host: %s",
+				result.Bootstrap, role, b.ID, check.Advertised, check.ResolveError))
+		case !check.Reachable:
+			result.Mismatch = append(result.Mismatch, fmt.Sprintf("bootstrap %s is reachable but %s %d advertises %s (%v), which cannot be connected from this host: %s",
+				result.Bootstrap, role, b.ID, check.Advertised, check.Resolved, check.DialError))
+		}
+	}
+	if len(result.Mismatch) > 0 {
+		result.Error = "advertised.listeners are not reachable from this client, reader/writer requests will time out; check the broker advertised.listeners configuration or client DNS/hosts"
+		return result
+	}
+	result.Success = "true"
+	return result
+}
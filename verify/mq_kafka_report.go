@@ -0,0 +1,162 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// 报告中输出的 topic 配置项
+var kafkaReportTopicConfigs = []string{
+	"min.insync.replicas",
+	"retention.ms",
+	"retention.bytes",
+	"cleanup.policy",
+}
+
+// 元数据中不存在的 broker（已下线）没有地址，ID 也无法还原，用 ? 表示
+func kafkaBrokerIDs(brokers []kafka.Broker) string {
+	ids := make([]string, 0, len(brokers))
+	for _, b := range brokers {
+		if b.Host == "" {
+			ids = append(ids, "?")
+			continue
+		}
+		ids = append(ids, fmt.Sprintf("%d", b.ID))
+	}
+	return "[" + strings.Join(ids, ",") + "]"
+}
+
+// 查询单个 broker 支持的 API 版本
+func readKafkaApiVersions(ctx context.Context, client *kafka.Client, b kafka.Broker) (map[string]string, error) {
+	resp, err := client.ApiVersions(ctx, &kafka.ApiVersionsRequest{
+		Addr: kafka.TCP(net.JoinHostPort(b.Host, fmt.Sprintf("%d", b.Port))),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	versions := make(map[string]string, len(resp.ApiKeys))
+	for _, k := range resp.ApiKeys {
+		versions[k.ApiName] = fmt.Sprintf("%d-%d", k.MinVersion, k.MaxVersion)
+	}
+	return versions, nil
+}
+
+// 查询 topic 的 min.insync.replicas 和保留策略配置
+func readKafkaTopicConfig(ctx context.Context, client *kafka.Client, topic string) (map[string]string, error) {
+	resp, err := client.DescribeConfigs(ctx, &kafka.DescribeConfigsRequest{
+		Resources: []kafka.DescribeConfigRequestResource{{
+			ResourceType: kafka.ResourceTypeTopic,
+			ResourceName: topic,
+			ConfigNames:  kafkaReportTopicConfigs,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	configs := map[string]string{}
+	for _, r := range resp.Resources {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		for _, e := range r.ConfigEntries {
+			configs[e.ConfigName] = e.ConfigValue
+		}
+	}
+	return configs, nil
+}
+
+// 集群健康报告：broker、controller、topic 数量、副本不足和离线分区、API 版本及指定 topic 的配置
+func KafkaClusterReport(cfg MQConfig) KafkaReport {
+	report := KafkaReport{
+		Success:         "false",
+		UnderReplicated: []string{},
+		Offline:         []string{},
+	}
+	dialer, err := newKafkaDialer(cfg)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	client := newKafkaClient(cfg, dialer)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		report.Error = fmt.Sprintf("kafka metadata error: %v", err)
+		return report
+	}
+	report.ClusterID = meta.ClusterID
+	report.Controller = meta.Controller.ID
+	for _, b := range meta.Brokers {
+		rb := KafkaReportBroker{
+			ID:         b.ID,
+			Address:    net.JoinHostPort(b.Host, fmt.Sprintf("%d", b.Port)),
+			Rack:       b.Rack,
+			Controller: b.ID == meta.Controller.ID,
+		}
+		versions, err := readKafkaApiVersions(ctx, client, b)
+		if err != nil {
+			rb.ApiError = err.Error()
+			logger.DebugLog("kafka broker %d api versions error: %v", b.ID, err)
+		}
+		rb.ApiVersions = versions
+		report.Brokers = append(report.Brokers, rb)
+	}
+	slices.SortFunc(report.Brokers, func(a, b KafkaReportBroker) int { return a.ID - b.ID })
+
+	topicFound := false
+	for _, t := range meta.Topics {
+		report.Topics++
+		if t.Name == cfg.Topic {
+			topicFound = true
+		}
+		for _, p := range t.Partitions {
+			report.Partitions++
+			name := fmt.Sprintf("%s-%d", t.Name, p.ID)
+			if p.Leader.Host == "" {
+				report.Offline = append(report.Offline, fmt.Sprintf("%s: no leader, replicas %s", name, kafkaBrokerIDs(p.Replicas)))
+				continue
+			}
+			if len(p.Isr) < len(p.Replicas) {
+				report.UnderReplicated = append(report.UnderReplicated, fmt.Sprintf("%s: isr %s, replicas %s", name, kafkaBrokerIDs(p.Isr), kafkaBrokerIDs(p.Replicas)))
+			}
+		}
+	}
+	logger.DebugLog("KafkaClusterReport: brokers=%d topics=%d partitions=%d", len(report.Brokers), report.Topics, report.Partitions)
+
+	if cfg.Topic != "" {
+		report.Topic = cfg.Topic
+		if !topicFound {
+			report.TopicError = fmt.Sprintf("topic %s does not exist", cfg.Topic)
+		} else if configs, err := readKafkaTopicConfig(ctx, client, cfg.Topic); err != nil {
+			report.TopicError = fmt.Sprintf("kafka describe configs error: %v", err)
+		} else {
+			report.TopicConfig = configs
+		}
+	}
+	report.Success = "true"
+	if len(report.Offline) > 0 {
+		report.Success = "false"
+		report.Error = fmt.Sprintf("%d offline partitions", len(report.Offline))
+	}
+	return report
+}
+
+func KafkaClusterReportJson(cfg MQConfig) []byte {
+	res := KafkaClusterReport(cfg)
+	b, _ := json.Marshal(res)
+	return b
+}
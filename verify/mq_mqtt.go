@@ -0,0 +1,420 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/eclipse/paho.golang/paho"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const mqttDefaultTopicPrefix = "precheck"
+
+type mqttMessage struct {
+	topic    string
+	payload  []byte
+	qos      byte
+	retained bool
+}
+
+// MQTT 3.1/3.1.1 和 5 使用不同的客户端库：paho.golang 只实现 MQTT 5 的报文格式，无法连接只支持
+// 3.1.1 的 broker（如 Azure IoT Hub、旧版本 Mosquitto）；paho.mqtt.golang 不支持 MQTT 5 的属性和原因码。
+// 检测流程只依赖以下操作
+type mqttSession interface {
+	subscribe(topic string, qos byte) (byte, error)
+	unsubscribe(topic string) error
+	publish(topic string, qos byte, retained bool, payload []byte) error
+	close()
+}
+
+// 本次检测使用的唯一 topic，所有测试消息都发布在其下
+func mqttTopic(cfg MQConfig) string {
+	prefix := strings.TrimRight(cfg.MQTTTopicPrefix, "/")
+	if prefix == "" {
+		prefix = mqttDefaultTopicPrefix
+	}
+	return prefix + "/" + cfg.RunID
+}
+
+func mqttClientID(cfg MQConfig) string {
+	return "precheck-" + cfg.RunID
+}
+
+// 收到的消息放入带缓冲的 channel，检测流程不读取时丢弃，避免阻塞客户端
+func deliverMQTTMessage(msgs chan<- mqttMessage, m mqttMessage) {
+	select {
+	case msgs <- m:
+	default:
+	}
+}
+
+type mqtt3Session struct {
+	client mqtt.Client
+	msgs   chan<- mqttMessage
+}
+
+func mqttWait(token mqtt.Token, op string) error {
+	if !token.WaitTimeout(10 * time.Second) {
+		return fmt.Errorf("mqtt %s timeout", op)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("mqtt %s error: %v", op, err)
+	}
+	return nil
+}
+
+func dialMQTT3(cfg MQConfig, version uint, msgs chan<- mqttMessage, result map[string]string) (mqttSession, error) {
+	opts := mqtt.NewClientOptions()
+	for _, server := range cfg.Servers {
+		// --tls 时明文地址也按 TLS 连接，与 MQTT 5 的处理一致
+		if cfg.TLS {
+			server = strings.Replace(strings.Replace(server, "tcp://", "ssl://", 1), "mqtt://", "mqtts://", 1)
+		}
+		opts.AddBroker(server)
+	}
+	opts.SetClientID(mqttClientID(cfg))
+	opts.SetUsername(cfg.User)
+	opts.SetPassword(cfg.Password)
+	opts.SetProtocolVersion(version)
+	opts.SetCleanSession(true)
+	opts.SetAutoReconnect(false)
+	opts.SetConnectTimeout(10 * time.Second)
+	opts.SetOrderMatters(false)
+	// 只在 ssl:// 等 TLS 地址上生效
+	tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	opts.SetTLSConfig(tlsCfg)
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if err := mqttWait(token, "connect"); err != nil {
+		return nil, err
+	}
+	if ct, ok := token.(*mqtt.ConnectToken); ok {
+		result["session_present"] = fmt.Sprintf("%v", ct.SessionPresent())
+	}
+	return &mqtt3Session{client: client, msgs: msgs}, nil
+}
+
+func (s *mqtt3Session) subscribe(topic string, qos byte) (byte, error) {
+	token := s.client.Subscribe(topic, qos, func(_ mqtt.Client, m mqtt.Message) {
+		deliverMQTTMessage(s.msgs, mqttMessage{topic: m.Topic(), payload: m.Payload(), qos: m.Qos(), retained: m.Retained()})
+	})
+	if err := mqttWait(token, "subscribe"); err != nil {
+		return 0, err
+	}
+	granted, ok := token.(*mqtt.SubscribeToken).Result()[topic]
+	if !ok || granted == 0x80 {
+		return 0, fmt.Errorf("mqtt subscribe %s rejected", topic)
+	}
+	return granted, nil
+}
+
+func (s *mqtt3Session) unsubscribe(topic string) error {
+	return mqttWait(s.client.Unsubscribe(topic), "unsubscribe")
+}
+
+func (s *mqtt3Session) publish(topic string, qos byte, retained bool, payload []byte) error {
+	return mqttWait(s.client.Publish(topic, qos, retained, payload), "publish")
+}
+
+func (s *mqtt3Session) close() {
+	s.client.Disconnect(250)
+}
+
+type mqtt5Session struct {
+	client *paho.Client
+}
+
+// 按地址的 scheme 建立 TCP 或 TLS 连接，依次尝试配置的地址
+func dialMQTT5Conn(cfg MQConfig) (net.Conn, string, error) {
+	var lastErr error
+	for _, server := range cfg.Servers {
+		u, err := url.Parse(server)
+		if err != nil || u.Host == "" {
+			lastErr = fmt.Errorf("invalid mqtt server %s", server)
+			continue
+		}
+		secure := cfg.TLS
+		switch u.Scheme {
+		case "ssl", "tls", "mqtts":
+			secure = true
+		case "tcp", "mqtt":
+		default:
+			lastErr = fmt.Errorf("unsupported mqtt scheme %s", u.Scheme)
+			continue
+		}
+		addr := u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "1883")
+			if secure {
+				addr = net.JoinHostPort(u.Hostname(), "8883")
+			}
+		}
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		var conn net.Conn
+		if secure {
+			tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+			if err != nil {
+				return nil, "", err
+			}
+			if tlsCfg.ServerName == "" && !tlsCfg.InsecureSkipVerify {
+				tlsCfg.ServerName = u.Hostname()
+			}
+			conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsCfg)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+		} else {
+			conn, err = dialer.Dial("tcp", addr)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+		}
+		return conn, server, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no mqtt server configured")
+	}
+	return nil, "", fmt.Errorf("mqtt dial error: %v", lastErr)
+}
+
+func dialMQTT5(cfg MQConfig, msgs chan<- mqttMessage, result map[string]string) (mqttSession, error) {
+	conn, server, err := dialMQTT5Conn(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := paho.NewClient(paho.ClientConfig{
+		ClientID: mqttClientID(cfg),
+		Conn:     conn,
+		OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+			func(pr paho.PublishReceived) (bool, error) {
+				deliverMQTTMessage(msgs, mqttMessage{topic: pr.Packet.Topic, payload: pr.Packet.Payload, qos: pr.Packet.QoS, retained: pr.Packet.Retain})
+				return true, nil
+			},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ack, err := client.Connect(ctx, &paho.Connect{
+		ClientID:     mqttClientID(cfg),
+		KeepAlive:    30,
+		CleanStart:   true,
+		Username:     cfg.User,
+		UsernameFlag: cfg.User != "",
+		Password:     []byte(cfg.Password),
+		PasswordFlag: cfg.Password != "",
+	})
+	if err != nil {
+		conn.Close()
+		if ack != nil && ack.Properties != nil && ack.Properties.ReasonString != "" {
+			return nil, fmt.Errorf("mqtt connect error: reason code %d: %s", ack.ReasonCode, ack.Properties.ReasonString)
+		}
+		return nil, fmt.Errorf("mqtt connect error: %v", err)
+	}
+	result["server"] = server
+	result["session_present"] = fmt.Sprintf("%v", ack.SessionPresent)
+	if p := ack.Properties; p != nil {
+		if p.MaximumQoS != nil {
+			result["max_qos"] = fmt.Sprintf("%d", *p.MaximumQoS)
+		}
+		result["retain_available"] = fmt.Sprintf("%v", p.RetainAvailable)
+	}
+	return &mqtt5Session{client: client}, nil
+}
+
+func (s *mqtt5Session) subscribe(topic string, qos byte) (byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ack, err := s.client.Subscribe(ctx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{{Topic: topic, QoS: qos}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("mqtt subscribe error: %v", err)
+	}
+	// 5.0 的 SUBACK 原因码小于 0x80 时即为授予的 QoS
+	if len(ack.Reasons) == 0 || ack.Reasons[0] >= 0x80 {
+		return 0, fmt.Errorf("mqtt subscribe %s rejected: %v", topic, ack.Reasons)
+	}
+	return ack.Reasons[0], nil
+}
+
+func (s *mqtt5Session) unsubscribe(topic string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := s.client.Unsubscribe(ctx, &paho.Unsubscribe{Topics: []string{topic}}); err != nil {
+		return fmt.Errorf("mqtt unsubscribe error: %v", err)
+	}
+	return nil
+}
+
+func (s *mqtt5Session) publish(topic string, qos byte, retained bool, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := s.client.Publish(ctx, &paho.Publish{Topic: topic, QoS: qos, Retain: retained, Payload: payload})
+	if err != nil {
+		return fmt.Errorf("mqtt publish error: %v", err)
+	}
+	if resp != nil && resp.ReasonCode >= 0x80 {
+		return fmt.Errorf("mqtt publish rejected: reason code %d", resp.ReasonCode)
+	}
+	return nil
+}
+
+func (s *mqtt5Session) close() {
+	_ = s.client.Disconnect(&paho.Disconnect{ReasonCode: 0})
+}
+
+// 按 --mqtt-version 选择协议版本建立会话
+func dialMQTT(cfg MQConfig, msgs chan<- mqttMessage, result map[string]string) (mqttSession, error) {
+	version := cfg.MQTTVersion
+	if version == "" {
+		version = "3.1.1"
+	}
+	result["protocol"] = version
+	switch version {
+	case "3.1":
+		return dialMQTT3(cfg, 3, msgs, result)
+	case "3.1.1":
+		return dialMQTT3(cfg, 4, msgs, result)
+	case "5":
+		return dialMQTT5(cfg, msgs, result)
+	default:
+		return nil, fmt.Errorf("unsupported mqtt version: %s", version)
+	}
+}
+
+// 等待指定 topic 上的消息，超时返回 false
+func waitMQTTMessage(msgs <-chan mqttMessage, topic string, timeout time.Duration) (mqttMessage, bool) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case m := <-msgs:
+			if m.topic == topic {
+				return m, true
+			}
+		case <-deadline:
+			return mqttMessage{}, false
+		}
+	}
+}
+
+func mqttConnect(cfg MQConfig, result map[string]string) error {
+	sess, err := dialMQTT(cfg, make(chan mqttMessage, 16), result)
+	if err != nil {
+		return err
+	}
+	sess.close()
+	result["client_id"] = mqttClientID(cfg)
+	return nil
+}
+
+// 订阅本次 topic 后依次以 QoS 0/1/2 发布，确认每个级别的消息都按原 QoS 收到
+func mqttWrite(cfg MQConfig, msg string, result map[string]string) error {
+	msgs := make(chan mqttMessage, 16)
+	sess, err := dialMQTT(cfg, msgs, result)
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+	base := mqttTopic(cfg)
+	filter := base + "/#"
+	granted, err := sess.subscribe(filter, 2)
+	if err != nil {
+		return err
+	}
+	defer sess.unsubscribe(filter)
+	result["topic"] = base
+	result["granted_qos"] = fmt.Sprintf("%d", granted)
+	var ok, failed []string
+	for qos := byte(0); qos <= 2; qos++ {
+		key := fmt.Sprintf("qos%d", qos)
+		topic := fmt.Sprintf("%s/%s", base, key)
+		payload := []byte(fmt.Sprintf("%s %s", msg, key))
+		start := time.Now()
+		if err := sess.publish(topic, qos, false, payload); err != nil {
+			result[key] = err.Error()
+			failed = append(failed, key)
+			continue
+		}
+		m, received := waitMQTTMessage(msgs, topic, 5*time.Second)
+		switch {
+		case !received:
+			result[key] = "not received within 5s"
+		case !bytes.Equal(m.payload, payload):
+			result[key] = fmt.Sprintf("payload mismatch: %s", string(m.payload))
+		case m.qos != qos:
+			result[key] = fmt.Sprintf("delivered with qos %d", m.qos)
+		default:
+			result[key] = "ok"
+			result[key+"_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+			ok = append(ok, fmt.Sprintf("%d", qos))
+			continue
+		}
+		failed = append(failed, key)
+	}
+	result["qos_ok"] = strings.Join(ok, ",")
+	logger.DebugLog("mqtt qos check: topic=%s granted=%d ok=%v failed=%v", base, granted, ok, failed)
+	if len(failed) > 0 {
+		return fmt.Errorf("mqtt qos check failed: %s", strings.Join(failed, ","))
+	}
+	return nil
+}
+
+// 设置保留消息，新订阅应立即收到；再发布空消息清除，重新订阅后不应再收到
+func mqttRetained(cfg MQConfig, result map[string]string) error {
+	msgs := make(chan mqttMessage, 16)
+	sess, err := dialMQTT(cfg, msgs, result)
+	if err != nil {
+		return err
+	}
+	defer sess.close()
+	topic := mqttTopic(cfg) + "/retained"
+	payload := []byte("retained " + cfg.RunID)
+	if err := sess.publish(topic, 1, true, payload); err != nil {
+		return err
+	}
+	cleared := false
+	// 中途失败时也清除保留消息，避免残留在 broker 上
+	defer func() {
+		if !cleared {
+			_ = sess.publish(topic, 1, true, nil)
+		}
+	}()
+	if _, err := sess.subscribe(topic, 1); err != nil {
+		return err
+	}
+	m, received := waitMQTTMessage(msgs, topic, 5*time.Second)
+	if !received || !m.retained || !bytes.Equal(m.payload, payload) {
+		return fmt.Errorf("retained message not delivered on %s", topic)
+	}
+	result["retain_set"] = "true"
+	if err := sess.unsubscribe(topic); err != nil {
+		return err
+	}
+	if err := sess.publish(topic, 1, true, nil); err != nil {
+		return err
+	}
+	cleared = true
+	if _, err := sess.subscribe(topic, 1); err != nil {
+		return err
+	}
+	defer sess.unsubscribe(topic)
+	if m, received := waitMQTTMessage(msgs, topic, 2*time.Second); received && len(m.payload) > 0 {
+		return fmt.Errorf("retained message on %s still delivered after clear", topic)
+	}
+	result["retain_cleared"] = "true"
+	return nil
+}
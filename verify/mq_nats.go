@@ -0,0 +1,293 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	natsDefaultStream = "PRECHECK"
+	natsRunIDHeader   = "Precheck-Run-Id"
+)
+
+func natsStream(cfg MQConfig) string {
+	if cfg.Stream == "" {
+		return natsDefaultStream
+	}
+	return cfg.Stream
+}
+
+// 本次检测使用的 subject，未指定时使用以 run ID 结尾的 subject
+func natsSubject(cfg MQConfig) string {
+	if cfg.Subject == "" {
+		return "precheck." + cfg.RunID
+	}
+	return cfg.Subject
+}
+
+// 本次检测使用的临时 pull consumer
+func natsConsumer(cfg MQConfig) string {
+	return "precheck-" + cfg.RunID
+}
+
+// 按配置选择认证方式：creds 文件、NKey seed、token 或用户名密码
+func natsOptions(cfg MQConfig) ([]nats.Option, error) {
+	opts := []nats.Option{
+		nats.Name("checker-middleware"),
+		nats.Timeout(10 * time.Second),
+	}
+	switch {
+	case cfg.CredsFile != "":
+		opts = append(opts, nats.UserCredentials(cfg.CredsFile))
+	case cfg.NKeySeedFile != "":
+		opt, err := nats.NkeyOptionFromSeed(cfg.NKeySeedFile)
+		if err != nil {
+			return nil, fmt.Errorf("nats nkey seed error: %v", err)
+		}
+		opts = append(opts, opt)
+	case cfg.Token != "":
+		opts = append(opts, nats.Token(cfg.Token))
+	case cfg.User != "":
+		opts = append(opts, nats.UserInfo(cfg.User, cfg.Password))
+	}
+	if cfg.TLS {
+		tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Secure(tlsCfg))
+	}
+	return opts, nil
+}
+
+func dialNATS(cfg MQConfig) (*nats.Conn, error) {
+	opts, err := natsOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	nc, err := nats.Connect(strings.Join(cfg.Servers, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("nats connect error: %v", err)
+	}
+	return nc, nil
+}
+
+// 连接并输出服务端信息；JetStream 模式下检查账户是否启用 JetStream，并检查或创建 stream
+func natsConnect(cfg MQConfig, result map[string]string) error {
+	nc, err := dialNATS(cfg)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	result["server"] = nc.ConnectedUrlRedacted()
+	result["version"] = nc.ConnectedServerVersion()
+	if name := nc.ConnectedClusterName(); name != "" {
+		result["cluster"] = name
+	}
+	if rtt, err := nc.RTT(); err == nil {
+		result["rtt_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(rtt))
+	}
+	if !cfg.JetStream {
+		return nil
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return fmt.Errorf("jetstream error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := js.AccountInfo(ctx); err != nil {
+		return fmt.Errorf("jetstream account error: %v", err)
+	}
+	name := natsStream(cfg)
+	result["stream"] = name
+	_, err = js.Stream(ctx, name)
+	if err == nil {
+		result["stream_created"] = "false"
+		return nil
+	}
+	if !errors.Is(err, jetstream.ErrStreamNotFound) {
+		return fmt.Errorf("jetstream stream error: %v", err)
+	}
+	if cfg.StreamVerifyOnly {
+		return fmt.Errorf("stream %s does not exist", name)
+	}
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     name,
+		Subjects: []string{natsSubject(cfg)},
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		return fmt.Errorf("jetstream create stream error: %v", err)
+	}
+	result["stream_created"] = "true"
+	logger.DebugLog("jetstream stream %s created", name)
+	return nil
+}
+
+// core NATS 请求/应答：在同一连接上订阅并回显，再发起请求
+func natsRequestReply(nc *nats.Conn, cfg MQConfig, msg string, result map[string]string) error {
+	subject := natsSubject(cfg)
+	sub, err := nc.Subscribe(subject, func(m *nats.Msg) {
+		_ = m.Respond(m.Data)
+	})
+	if err != nil {
+		return fmt.Errorf("nats subscribe error: %v", err)
+	}
+	defer sub.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		return fmt.Errorf("nats flush error: %v", err)
+	}
+	start := time.Now()
+	reply, err := nc.Request(subject, []byte(msg), 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("nats request error: %v", err)
+	}
+	if !bytes.Equal(reply.Data, []byte(msg)) {
+		return fmt.Errorf("nats reply mismatch: %s", string(reply.Data))
+	}
+	result["subject"] = subject
+	result["request_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	return nil
+}
+
+// 写入：core 模式做请求/应答，JetStream 模式发布并等待 ack
+func natsWrite(cfg MQConfig, msg string, result map[string]string) error {
+	nc, err := dialNATS(cfg)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	if !cfg.JetStream {
+		return natsRequestReply(nc, cfg, msg, result)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return fmt.Errorf("jetstream error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	subject := natsSubject(cfg)
+	start := time.Now()
+	m := nats.NewMsg(subject)
+	m.Data = []byte(msg)
+	m.Header.Set(natsRunIDHeader, cfg.RunID)
+	// 同一 run ID 可能多次发布（如消息大小探测），消息 ID 需每次不同，避免被 JetStream 去重
+	ack, err := js.PublishMsg(ctx, m, jetstream.WithMsgID(fmt.Sprintf("%s-%d", cfg.RunID, start.UnixNano())))
+	if err != nil {
+		return fmt.Errorf("jetstream publish error (subject=%s): %v", subject, err)
+	}
+	result["ack_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	result["subject"] = subject
+	result["stream"] = ack.Stream
+	result["sequence"] = fmt.Sprintf("%d", ack.Sequence)
+	result["duplicate"] = fmt.Sprintf("%v", ack.Duplicate)
+	result["run_id"] = cfg.RunID
+	return nil
+}
+
+// 通过临时 pull consumer 拉取本次消息并 ack，删除该消息和 consumer，避免在已有 stream 中留下检测数据
+func natsConsume(cfg MQConfig, result map[string]string) error {
+	nc, err := dialNATS(cfg)
+	if err != nil {
+		return err
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return fmt.Errorf("jetstream error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	name := natsStream(cfg)
+	stream, err := js.Stream(ctx, name)
+	if err != nil {
+		return fmt.Errorf("jetstream stream error: %v", err)
+	}
+	since := time.Now().Add(-time.Minute)
+	cons, err := stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Name:          natsConsumer(cfg),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		FilterSubject: natsSubject(cfg),
+		DeliverPolicy: jetstream.DeliverByStartTimePolicy,
+		OptStartTime:  &since,
+	})
+	if err != nil {
+		return fmt.Errorf("jetstream create consumer error: %v", err)
+	}
+	defer func() {
+		if err := js.DeleteConsumer(context.Background(), name, natsConsumer(cfg)); err != nil {
+			result["consumer_delete_error"] = err.Error()
+			return
+		}
+		result["consumer_deleted"] = "true"
+	}()
+	start := time.Now()
+	skipped := 0
+	for ctx.Err() == nil {
+		batch, err := cons.Fetch(10, jetstream.FetchMaxWait(2*time.Second))
+		if err != nil {
+			return fmt.Errorf("jetstream fetch error: %v", err)
+		}
+		for m := range batch.Messages() {
+			if err := m.Ack(); err != nil {
+				return fmt.Errorf("jetstream ack error: %v", err)
+			}
+			if m.Headers().Get(natsRunIDHeader) != cfg.RunID {
+				skipped++
+				continue
+			}
+			meta, err := m.Metadata()
+			if err != nil {
+				return fmt.Errorf("jetstream metadata error: %v", err)
+			}
+			result["fetch_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+			result["sequence"] = fmt.Sprintf("%d", meta.Sequence.Stream)
+			result["skipped"] = fmt.Sprintf("%d", skipped)
+			if err := stream.DeleteMsg(ctx, meta.Sequence.Stream); err != nil {
+				return fmt.Errorf("jetstream delete message error: %v", err)
+			}
+			result["message_deleted"] = "true"
+			return nil
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) {
+			return fmt.Errorf("jetstream fetch error: %v", err)
+		}
+	}
+	return fmt.Errorf("run id %s not fetched after skipping %d messages: %v", cfg.RunID, skipped, ctx.Err())
+}
+
+// 删除本工具创建的 stream
+func cleanupNATS(cfg MQConfig) map[string]string {
+	result := map[string]string{"success": "false"}
+	nc, err := dialNATS(cfg)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		result["error"] = fmt.Sprintf("jetstream error: %v", err)
+		return result
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := js.DeleteStream(ctx, natsStream(cfg)); err != nil {
+		result["error"] = fmt.Sprintf("jetstream delete stream error: %v", err)
+		return result
+	}
+	result["stream"] = natsStream(cfg)
+	result["success"] = "true"
+	return result
+}
@@ -0,0 +1,228 @@
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+const pulsarRunIDProperty = "precheck-run-id"
+
+// 完整的 topic 名称，已带 persistent:// 等前缀时直接使用
+func pulsarTopic(cfg MQConfig) string {
+	if strings.Contains(cfg.Topic, "://") {
+		return cfg.Topic
+	}
+	tenant, namespace := cfg.Tenant, cfg.Namespace
+	if tenant == "" {
+		tenant = "public"
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("persistent://%s/%s/%s", tenant, namespace, cfg.Topic)
+}
+
+// 本次检测使用的临时订阅
+func pulsarSubscription(cfg MQConfig) string {
+	return "precheck-" + cfg.RunID
+}
+
+// token 优先，其次使用客户端证书做 TLS 认证
+func newPulsarClient(cfg MQConfig) (pulsar.Client, error) {
+	opts := pulsar.ClientOptions{
+		URL:                        cfg.ServiceURL,
+		ConnectionTimeout:          10 * time.Second,
+		OperationTimeout:           30 * time.Second,
+		TLSTrustCertsFilePath:      cfg.TLSCAFile,
+		TLSAllowInsecureConnection: cfg.TLSSkipVerify,
+	}
+	switch {
+	case cfg.Token != "":
+		opts.Authentication = pulsar.NewAuthenticationToken(cfg.Token)
+	case cfg.TLSCertFile != "" && cfg.TLSKeyFile != "":
+		opts.Authentication = pulsar.NewAuthenticationTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	client, err := pulsar.NewClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("pulsar client error: %v", err)
+	}
+	return client, nil
+}
+
+// 通过 admin REST API 查询 topic 的分区元数据，确认租户和命名空间可访问
+func pulsarAdminPartitions(cfg MQConfig, topic string) (int, error) {
+	name := strings.SplitN(topic, "://", 2)
+	path := fmt.Sprintf("%s/admin/v2/%s/%s/partitions", strings.TrimRight(cfg.AdminURL, "/"), name[0], name[1])
+	u, err := url.Parse(path)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	if u.Scheme == "https" {
+		tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+		if err != nil {
+			return 0, err
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsCfg}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("GET %s: %s", u.Path, resp.Status)
+	}
+	var meta struct {
+		Partitions int `json:"partitions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return 0, err
+	}
+	return meta.Partitions, nil
+}
+
+// 连接 broker 并查询 topic 的分区，配置了 admin 地址时同时检查 admin API
+func pulsarConnect(cfg MQConfig, result map[string]string) error {
+	client, err := newPulsarClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	topic := pulsarTopic(cfg)
+	result["topic"] = topic
+	partitions, err := client.TopicPartitions(topic)
+	if err != nil {
+		return fmt.Errorf("pulsar topic lookup error: %v", err)
+	}
+	result["partitions"] = fmt.Sprintf("%d", len(partitions))
+	if cfg.AdminURL != "" {
+		n, err := pulsarAdminPartitions(cfg, topic)
+		if err != nil {
+			return fmt.Errorf("pulsar admin error: %v", err)
+		}
+		result["admin_partitions"] = fmt.Sprintf("%d", n)
+	}
+	logger.DebugLog("pulsar connect: topic=%s partitions=%v", topic, partitions)
+	return nil
+}
+
+// 创建 producer 发送一条带 run ID 属性的消息
+func pulsarSend(client pulsar.Client, topic, runID, msg string, result map[string]string) (pulsar.MessageID, error) {
+	producer, err := client.CreateProducer(pulsar.ProducerOptions{Topic: topic})
+	if err != nil {
+		return nil, fmt.Errorf("pulsar producer error: %v", err)
+	}
+	defer producer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	id, err := producer.Send(ctx, &pulsar.ProducerMessage{
+		Payload:    []byte(msg),
+		Key:        runID,
+		Properties: map[string]string{pulsarRunIDProperty: runID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pulsar send error: %v", err)
+	}
+	result["send_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	return id, nil
+}
+
+// 先建立临时订阅，保证发送的消息被保留，再发送一条带 run ID 的消息
+func pulsarWrite(cfg MQConfig, msg string, result map[string]string) error {
+	client, err := newPulsarClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	topic := pulsarTopic(cfg)
+	consumer, err := client.Subscribe(pulsar.ConsumerOptions{
+		Topic:                       topic,
+		SubscriptionName:            pulsarSubscription(cfg),
+		Type:                        pulsar.Exclusive,
+		SubscriptionInitialPosition: pulsar.SubscriptionPositionLatest,
+	})
+	if err != nil {
+		return fmt.Errorf("pulsar subscribe error: %v", err)
+	}
+	// 订阅需在删除阶段之前关闭；发送失败时取消订阅，避免残留的订阅积压消息
+	defer consumer.Close()
+	id, err := pulsarSend(client, topic, cfg.RunID, msg, result)
+	if err != nil {
+		if uerr := consumer.Unsubscribe(); uerr != nil {
+			result["unsubscribe_error"] = uerr.Error()
+		}
+		return err
+	}
+	result["run_id"] = cfg.RunID
+	result["message_id"] = fmt.Sprintf("%v", id)
+	result["subscription"] = pulsarSubscription(cfg)
+	logger.DebugLog("pulsar sent: topic=%s id=%v", topic, id)
+	return nil
+}
+
+// 通过临时订阅读取本次消息并 ack，结束后取消订阅
+func pulsarConsume(cfg MQConfig, result map[string]string) error {
+	client, err := newPulsarClient(cfg)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	consumer, err := client.Subscribe(pulsar.ConsumerOptions{
+		Topic:                       pulsarTopic(cfg),
+		SubscriptionName:            pulsarSubscription(cfg),
+		Type:                        pulsar.Exclusive,
+		SubscriptionInitialPosition: pulsar.SubscriptionPositionEarliest,
+	})
+	if err != nil {
+		return fmt.Errorf("pulsar subscribe error: %v", err)
+	}
+	defer consumer.Close()
+	defer func() {
+		if err := consumer.Unsubscribe(); err != nil {
+			result["unsubscribe_error"] = err.Error()
+			return
+		}
+		result["unsubscribed"] = "true"
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	start := time.Now()
+	skipped := 0
+	for {
+		m, err := consumer.Receive(ctx)
+		if err != nil {
+			return fmt.Errorf("run id %s not consumed after skipping %d messages: %v", cfg.RunID, skipped, err)
+		}
+		if err := consumer.Ack(m); err != nil {
+			return fmt.Errorf("pulsar ack error: %v", err)
+		}
+		if m.Properties()[pulsarRunIDProperty] != cfg.RunID {
+			skipped++
+			continue
+		}
+		result["consume_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+		result["e2e_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(m.PublishTime())))
+		result["message_id"] = fmt.Sprintf("%v", m.ID())
+		result["skipped"] = fmt.Sprintf("%d", skipped)
+		return nil
+	}
+}
@@ -0,0 +1,156 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQ 默认 vhost
+func rabbitmqVhost(cfg MQConfig) string {
+	if cfg.Vhost == "" {
+		return "/"
+	}
+	return cfg.Vhost
+}
+
+// 只包含协议和地址的连接 URL，用户名、密码和 vhost 通过 amqp.Config 传递，避免特殊字符需要转义
+func rabbitmqURL(cfg MQConfig) string {
+	u := url.URL{
+		Scheme: "amqp",
+		Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:   "/",
+	}
+	if cfg.TLS {
+		u.Scheme = "amqps"
+	}
+	return u.String()
+}
+
+// 根据认证机制构造 SASL 配置
+func rabbitmqAuth(cfg MQConfig) ([]amqp.Authentication, error) {
+	switch strings.ToLower(cfg.AuthMechanism) {
+	case "", "plain":
+		return []amqp.Authentication{&amqp.PlainAuth{Username: cfg.User, Password: cfg.Password}}, nil
+	case "amqplain":
+		return []amqp.Authentication{&amqp.AMQPlainAuth{Username: cfg.User, Password: cfg.Password}}, nil
+	case "external":
+		// EXTERNAL 使用客户端证书中的身份认证，必须启用 TLS
+		if !cfg.TLS || cfg.TLSCertFile == "" {
+			return nil, fmt.Errorf("external auth requires --tls with a client certificate")
+		}
+		return []amqp.Authentication{&amqp.ExternalAuth{}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth mechanism: %s", cfg.AuthMechanism)
+	}
+}
+
+// 建立 RabbitMQ 连接
+func dialRabbitMQ(cfg MQConfig) (*amqp.Connection, error) {
+	auth, err := rabbitmqAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	config := amqp.Config{
+		SASL:  auth,
+		Vhost: rabbitmqVhost(cfg),
+		Dial:  amqp.DefaultDial(10 * time.Second),
+	}
+	if cfg.TLS {
+		tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		config.TLSClientConfig = tlsCfg
+	}
+	u := rabbitmqURL(cfg)
+	logger.DebugLog("rabbitmq connect url: %s vhost=%s user=%s auth=%s", u, config.Vhost, cfg.User, auth[0].Mechanism())
+	conn, err := amqp.DialConfig(u, config)
+	if err != nil {
+		return nil, fmt.Errorf("rabbitmq connect error (vhost=%s): %v", config.Vhost, err)
+	}
+	return conn, nil
+}
+
+// 以 confirm 模式发布一条持久化消息，mandatory 保证无法路由时 broker 退回消息
+func publishRabbitMQRunMessage(conn *amqp.Connection, cfg MQConfig, exchange, key, msg string, result map[string]string) error {
+	// broker 触发内存或磁盘告警时会通过 connection.blocked 阻塞发布者
+	blocked := conn.NotifyBlocked(make(chan amqp.Blocking, 4))
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("rabbitmq channel error: %v", err)
+	}
+	defer ch.Close()
+	if err := ch.Confirm(false); err != nil {
+		return fmt.Errorf("rabbitmq confirm mode error: %v", err)
+	}
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+	// 消息超过 max_message_size 等情况下 broker 会关闭 channel，记录关闭原因
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	returns := ch.NotifyReturn(make(chan amqp.Return, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result["routing_key"] = key
+	result["persistent"] = "true"
+	start := time.Now()
+	err = ch.PublishWithContext(ctx, exchange, key, true, false, amqp.Publishing{
+		ContentType:  "text/plain",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    cfg.RunID,
+		Body:         []byte(msg),
+	})
+	logger.DebugLog("rabbitmq write msg: exchange=%s routing_key=%s msg=%s", exchange, key, msg)
+	if err != nil {
+		return fmt.Errorf("rabbitmq write error: %v", err)
+	}
+	result["blocked"] = "false"
+	for {
+		select {
+		case b, ok := <-blocked:
+			if !ok {
+				blocked = nil
+				continue
+			}
+			if b.Active {
+				result["blocked"] = "true"
+				result["blocked_reason"] = b.Reason
+				return fmt.Errorf("broker is blocking publishers: %s", b.Reason)
+			}
+		case c, ok := <-confirms:
+			if !ok {
+				if e := <-closed; e != nil {
+					return fmt.Errorf("rabbitmq channel closed before confirm: %v", e)
+				}
+				return fmt.Errorf("rabbitmq channel closed before confirm")
+			}
+			result["confirm_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+			// broker 先发送 basic.return 再发送 basic.ack
+			select {
+			case r := <-returns:
+				result["confirm"] = "ack"
+				result["returned"] = "true"
+				return fmt.Errorf("message returned by broker: %d %s (exchange=%s routing_key=%s)", r.ReplyCode, r.ReplyText, r.Exchange, r.RoutingKey)
+			default:
+				result["returned"] = "false"
+			}
+			if !c.Ack {
+				result["confirm"] = "nack"
+				return fmt.Errorf("message nacked by broker")
+			}
+			result["confirm"] = "ack"
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("wait for publisher confirm timeout: %v", ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package verify
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// 使用指定节点地址的配置，节点未带端口时使用 Port
+func rabbitmqNodeConfig(cfg MQConfig, node string) MQConfig {
+	host, port, err := net.SplitHostPort(node)
+	if err != nil {
+		cfg.Host = node
+		return cfg
+	}
+	cfg.Host = host
+	if p, err := strconv.Atoi(port); err == nil {
+		cfg.Port = p
+	}
+	return cfg
+}
+
+// 单独连接一个节点，返回连接耗时和节点信息
+func rabbitmqNodeConnect(cfg MQConfig) map[string]string {
+	result := map[string]string{"success": "false"}
+	start := time.Now()
+	conn, err := dialRabbitMQ(cfg)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+		return result
+	}
+	ch.Close()
+	result["connect_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	if v, ok := conn.Properties["version"].(string); ok {
+		result["version"] = v
+	}
+	if v, ok := conn.Properties["cluster_name"].(string); ok {
+		result["cluster_name"] = v
+	}
+	result["success"] = "true"
+	return result
+}
+
+// 从队列中读取本次 run ID 的消息
+func consumeRabbitMQRunMessage(conn *amqp.Connection, cfg MQConfig, queue string, result map[string]string) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("rabbitmq channel error: %v", err)
+	}
+	defer ch.Close()
+	var args amqp.Table
+	if rabbitmqQueueType(cfg) == "stream" {
+		if err := ch.Qos(1, 0, false); err != nil {
+			return fmt.Errorf("rabbitmq qos error: %v", err)
+		}
+		args = amqp.Table{"x-stream-offset": "first"}
+	}
+	msgs, err := ch.Consume(queue, "", false, false, false, false, args)
+	if err != nil {
+		return fmt.Errorf("rabbitmq consume error: %v", err)
+	}
+	start := time.Now()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case d, ok := <-msgs:
+			if !ok {
+				return fmt.Errorf("rabbitmq channel closed before message consumed")
+			}
+			_ = d.Ack(false)
+			if d.MessageId != cfg.RunID {
+				continue
+			}
+			result["consume_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+			return nil
+		case <-timeout:
+			return fmt.Errorf("message not consumed from queue %s within 10s", queue)
+		}
+	}
+}
+
+// 在 from 节点发布、在 to 节点消费，验证节点之间的消息路由
+func rabbitmqCrossNode(cfg MQConfig, from, to, queue string) map[string]string {
+	result := map[string]string{"success": "false", "queue": queue}
+	pub, err := dialRabbitMQ(rabbitmqNodeConfig(cfg, from))
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	defer pub.Close()
+	ch, err := pub.Channel()
+	if err != nil {
+		result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+		return result
+	}
+	defer func() {
+		if _, err := ch.QueueDelete(queue, false, false, false); err != nil {
+			logger.DebugLog("rabbitmq cross node queue %s delete error: %v", queue, err)
+		}
+		ch.Close()
+	}()
+	if err := declareRabbitMQQueueOfType(ch, cfg, queue); err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	if err := publishRabbitMQRunMessage(pub, cfg, "", queue, fmt.Sprintf("hello %s from %s", cfg.RunID, from), result); err != nil {
+		result["error"] = fmt.Sprintf("publish on %s error: %v", from, err)
+		return result
+	}
+	sub, err := dialRabbitMQ(rabbitmqNodeConfig(cfg, to))
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	defer sub.Close()
+	if err := consumeRabbitMQRunMessage(sub, cfg, queue, result); err != nil {
+		result["error"] = fmt.Sprintf("consume on %s error: %v", to, err)
+		return result
+	}
+	result["success"] = "true"
+	return result
+}
+
+// 逐个连接集群节点，并按环形顺序在相邻节点之间做跨节点收发
+func RabbitMQClusterCheck(cfg MQConfig) (map[string]map[string]string, map[string]map[string]string) {
+	nodes := map[string]map[string]string{}
+	var up []string
+	for _, node := range cfg.Nodes {
+		nodes[node] = rabbitmqNodeConnect(rabbitmqNodeConfig(cfg, node))
+		if nodes[node]["success"] == "true" {
+			up = append(up, node)
+		}
+	}
+	if len(up) < 2 {
+		return nodes, nil
+	}
+	cross := map[string]map[string]string{}
+	for i, from := range up {
+		to := up[(i+1)%len(up)]
+		queue := fmt.Sprintf("precheck-%s-cross-%d", cfg.RunID, i)
+		cross[from+"->"+to] = rabbitmqCrossNode(cfg, from, to, queue)
+	}
+	return nodes, cross
+}
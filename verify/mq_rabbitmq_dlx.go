@@ -0,0 +1,251 @@
+package verify
+
+import (
+	"fmt"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// 未指定 --dead-letter-ttl 时临时队列的消息 TTL(毫秒)
+const rabbitmqDefaultDeadLetterTTL = 1000
+
+// 死信检测使用的临时 exchange 和队列，均以 run ID 命名
+type rabbitmqDeadLetterTopology struct {
+	exchange string // 死信 exchange
+	dlq      string // 绑定到死信 exchange 的死信队列
+	ttlQueue string // 带 x-message-ttl 的队列，消息过期后进入死信队列
+	nack     string // 只配置死信 exchange 的队列，消息被拒绝后进入死信队列
+}
+
+func newRabbitMQDeadLetterTopology(cfg MQConfig) rabbitmqDeadLetterTopology {
+	return rabbitmqDeadLetterTopology{
+		exchange: "precheck.dlx." + cfg.RunID,
+		dlq:      "precheck-dlq-" + cfg.RunID,
+		ttlQueue: "precheck-ttl-" + cfg.RunID,
+		nack:     "precheck-nack-" + cfg.RunID,
+	}
+}
+
+func rabbitmqDeadLetterTTL(cfg MQConfig) int {
+	if cfg.DeadLetterTTL <= 0 {
+		return rabbitmqDefaultDeadLetterTTL
+	}
+	return cfg.DeadLetterTTL
+}
+
+// 按 --queue-type 声明队列，quorum 队列必须持久化
+func declareRabbitMQDeadLetterQueue(ch *amqp.Channel, cfg MQConfig, queue string, args amqp.Table) error {
+	durable := false
+	if kind := rabbitmqQueueType(cfg); kind != "classic" {
+		durable = true
+		args["x-queue-type"] = kind
+	}
+	if _, err := ch.QueueDeclare(queue, durable, false, false, false, args); err != nil {
+		return fmt.Errorf("rabbitmq queue %s declare error: %v", queue, err)
+	}
+	return nil
+}
+
+// 声明死信 exchange、死信队列以及两个源队列。源队列死信沿用原 routing key，即源队列名
+func setupRabbitMQDeadLetter(ch *amqp.Channel, cfg MQConfig, t rabbitmqDeadLetterTopology) error {
+	if err := ch.ExchangeDeclare(t.exchange, amqp.ExchangeDirect, false, false, false, false, nil); err != nil {
+		return fmt.Errorf("rabbitmq exchange declare error: %v", err)
+	}
+	if err := declareRabbitMQDeadLetterQueue(ch, cfg, t.dlq, amqp.Table{}); err != nil {
+		return err
+	}
+	for _, queue := range []string{t.ttlQueue, t.nack} {
+		if err := ch.QueueBind(t.dlq, queue, t.exchange, false, nil); err != nil {
+			return fmt.Errorf("rabbitmq queue bind error: %v", err)
+		}
+	}
+	ttlArgs := amqp.Table{
+		"x-message-ttl":          int32(rabbitmqDeadLetterTTL(cfg)),
+		"x-dead-letter-exchange": t.exchange,
+	}
+	if err := declareRabbitMQDeadLetterQueue(ch, cfg, t.ttlQueue, ttlArgs); err != nil {
+		return err
+	}
+	return declareRabbitMQDeadLetterQueue(ch, cfg, t.nack, amqp.Table{"x-dead-letter-exchange": t.exchange})
+}
+
+// 删除本次创建的队列和死信 exchange
+func cleanupRabbitMQDeadLetter(conn *amqp.Connection, t rabbitmqDeadLetterTopology) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("rabbitmq channel error: %v", err)
+	}
+	defer ch.Close()
+	for _, queue := range []string{t.ttlQueue, t.nack, t.dlq} {
+		if _, err := ch.QueueDelete(queue, false, false, false); err != nil {
+			return fmt.Errorf("rabbitmq queue %s delete error: %v", queue, err)
+		}
+	}
+	if err := ch.ExchangeDelete(t.exchange, false, false); err != nil {
+		return fmt.Errorf("rabbitmq exchange delete error: %v", err)
+	}
+	return nil
+}
+
+// 读取 x-death 头中最近一次死信的原因和源队列
+func rabbitmqDeathReason(d amqp.Delivery) (string, string) {
+	deaths, ok := d.Headers["x-death"].([]interface{})
+	if !ok || len(deaths) == 0 {
+		return "", ""
+	}
+	death, ok := deaths[0].(amqp.Table)
+	if !ok {
+		return "", ""
+	}
+	reason, _ := death["reason"].(string)
+	queue, _ := death["queue"].(string)
+	return reason, queue
+}
+
+// 等待来自指定源队列的本次 run ID 死信消息，返回死信原因
+func waitRabbitMQDeadLetter(deliveries <-chan amqp.Delivery, cfg MQConfig, source string, timeout time.Duration) (string, error) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case d, ok := <-deliveries:
+			if !ok {
+				return "", fmt.Errorf("rabbitmq channel closed before dead letter consumed")
+			}
+			_ = d.Ack(false)
+			reason, queue := rabbitmqDeathReason(d)
+			if d.MessageId != cfg.RunID || queue != source {
+				continue
+			}
+			return reason, nil
+		case <-deadline:
+			return "", fmt.Errorf("message from %s not dead-lettered within %s", source, timeout)
+		}
+	}
+}
+
+// 从队列取出本次 run ID 的消息并以 requeue=false 拒绝
+func nackRabbitMQRunMessage(ch *amqp.Channel, cfg MQConfig, queue string) error {
+	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("rabbitmq consume error: %v", err)
+	}
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case d, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("rabbitmq channel closed before message consumed")
+			}
+			if d.MessageId != cfg.RunID {
+				_ = d.Ack(false)
+				continue
+			}
+			if err := d.Nack(false, false); err != nil {
+				return fmt.Errorf("rabbitmq nack error: %v", err)
+			}
+			return nil
+		case <-timeout:
+			return fmt.Errorf("message not consumed from queue %s within 10s", queue)
+		}
+	}
+}
+
+// 死信检测：消息在带 TTL 的队列中过期、以及被 nack(requeue=false) 后，都应进入死信队列
+func RabbitMQDeadLetter(cfg MQConfig) map[string]string {
+	result := map[string]string{
+		"success": "false",
+		"ttl":     "skip",
+		"nack":    "skip",
+	}
+	ttl := rabbitmqDeadLetterTTL(cfg)
+	if rabbitmqQueueType(cfg) == "stream" {
+		result["error"] = "stream queues do not support dead lettering"
+		return result
+	}
+	conn, err := dialRabbitMQ(cfg)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+		return result
+	}
+	defer ch.Close()
+
+	t := newRabbitMQDeadLetterTopology(cfg)
+	result["dead_letter_exchange"] = t.exchange
+	result["dead_letter_queue"] = t.dlq
+	result["message_ttl_ms"] = fmt.Sprintf("%d", ttl)
+	defer func() {
+		if err := cleanupRabbitMQDeadLetter(conn, t); err != nil {
+			result["cleanup_error"] = err.Error()
+		}
+	}()
+	if err := setupRabbitMQDeadLetter(ch, cfg, t); err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	deliveries, err := ch.Consume(t.dlq, "", false, false, false, false, nil)
+	if err != nil {
+		result["error"] = fmt.Sprintf("rabbitmq consume error: %v", err)
+		return result
+	}
+	// 死信的最长等待时间，TTL 之外留出 10 秒
+	wait := time.Duration(ttl)*time.Millisecond + 10*time.Second
+
+	// 过期死信：从发布确认开始计时
+	result["ttl"] = "false"
+	start := time.Now()
+	if err := publishRabbitMQRunMessage(conn, cfg, "", t.ttlQueue, "hello "+cfg.RunID+" ttl", map[string]string{}); err != nil {
+		result["ttl_error"] = fmt.Sprintf("publish error: %v", err)
+	} else if reason, err := waitRabbitMQDeadLetter(deliveries, cfg, t.ttlQueue, wait); err != nil {
+		result["ttl_error"] = err.Error()
+	} else {
+		result["ttl_dead_letter_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+		result["ttl_reason"] = reason
+		if reason == "expired" {
+			result["ttl"] = "true"
+		} else {
+			result["ttl_error"] = fmt.Sprintf("unexpected dead letter reason: %s", reason)
+		}
+	}
+
+	// 拒绝死信：从 nack 开始计时
+	result["nack"] = "false"
+	if err := publishRabbitMQRunMessage(conn, cfg, "", t.nack, "hello "+cfg.RunID+" nack", map[string]string{}); err != nil {
+		result["nack_error"] = fmt.Sprintf("publish error: %v", err)
+	} else {
+		nackCh, err := conn.Channel()
+		if err != nil {
+			result["nack_error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+		} else {
+			start := time.Now()
+			if err := nackRabbitMQRunMessage(nackCh, cfg, t.nack); err != nil {
+				result["nack_error"] = err.Error()
+			} else if reason, err := waitRabbitMQDeadLetter(deliveries, cfg, t.nack, 10*time.Second); err != nil {
+				result["nack_error"] = err.Error()
+			} else {
+				result["nack_dead_letter_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+				result["nack_reason"] = reason
+				if reason == "rejected" {
+					result["nack"] = "true"
+				} else {
+					result["nack_error"] = fmt.Sprintf("unexpected dead letter reason: %s", reason)
+				}
+			}
+			nackCh.Close()
+		}
+	}
+	logger.DebugLog("rabbitmq dead letter: ttl=%s nack=%s", result["ttl"], result["nack"])
+	if result["ttl"] == "true" && result["nack"] == "true" {
+		result["success"] = "true"
+	}
+	return result
+}
@@ -0,0 +1,194 @@
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"checker-middleware/pkg/logger"
+)
+
+// 文件句柄使用率超过该比例时告警
+const rabbitmqFDUsageLimit = 0.9
+
+type rabbitmqOverview struct {
+	ClusterName     string `json:"cluster_name"`
+	RabbitMQVersion string `json:"rabbitmq_version"`
+	ErlangVersion   string `json:"erlang_version"`
+}
+
+type rabbitmqAlarmCheck struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+	Alarms []struct {
+		Node     string `json:"node"`
+		Resource string `json:"resource"`
+	} `json:"alarms"`
+}
+
+type rabbitmqPermission struct {
+	Configure string `json:"configure"`
+	Write     string `json:"write"`
+	Read      string `json:"read"`
+}
+
+// 权限正则非空且匹配检测队列时认为具备该权限，正则无法解析时视为不具备并返回错误
+func rabbitmqPermissionAllows(pattern, resource string) (bool, error) {
+	if pattern == "" {
+		return false, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(resource), nil
+}
+
+// 检查节点运行状态、网络分区、文件句柄以及内存和磁盘告警
+func checkRabbitMQNodes(client *http.Client, cfg MQConfig, res *RabbitMQManagementResult) error {
+	if err := rabbitmqManagementGet(client, cfg, "/api/nodes", &res.Nodes); err != nil {
+		return err
+	}
+	for _, n := range res.Nodes {
+		if !n.Running {
+			res.Problems = append(res.Problems, fmt.Sprintf("node %s is not running", n.Name))
+			continue
+		}
+		res.RunningNodes++
+		if len(n.Partitions) > 0 {
+			res.Problems = append(res.Problems, fmt.Sprintf("node %s is partitioned from %v", n.Name, n.Partitions))
+		}
+		if n.FDTotal > 0 && float64(n.FDUsed) > float64(n.FDTotal)*rabbitmqFDUsageLimit {
+			res.Problems = append(res.Problems, fmt.Sprintf("node %s file descriptors %d/%d", n.Name, n.FDUsed, n.FDTotal))
+		}
+		if n.MemAlarm {
+			res.Problems = append(res.Problems, fmt.Sprintf("node %s memory alarm: used %d limit %d", n.Name, n.MemUsed, n.MemLimit))
+		}
+		if n.DiskFreeAlarm {
+			res.Problems = append(res.Problems, fmt.Sprintf("node %s disk alarm: free %d limit %d", n.Name, n.DiskFree, n.DiskFreeLimit))
+		}
+	}
+	return nil
+}
+
+// 集群级告警检查，告警生效时接口返回 503
+func checkRabbitMQAlarms(client *http.Client, cfg MQConfig, res *RabbitMQManagementResult) error {
+	status, body, err := rabbitmqManagementDo(client, cfg, "/api/health/checks/alarms")
+	if err != nil {
+		return err
+	}
+	// 3.8.10 之前的版本没有该接口，只依赖节点上的告警标记
+	if status == http.StatusNotFound {
+		return nil
+	}
+	var check rabbitmqAlarmCheck
+	if err := json.Unmarshal(body, &check); err != nil {
+		return fmt.Errorf("GET /api/health/checks/alarms: %d %s", status, string(body))
+	}
+	for _, a := range check.Alarms {
+		res.Alarms = append(res.Alarms, fmt.Sprintf("%s on %s", a.Resource, a.Node))
+	}
+	if check.Status != "ok" {
+		res.Problems = append(res.Problems, fmt.Sprintf("alarm health check failed: %s", check.Reason))
+	}
+	return nil
+}
+
+// 检测需要的 configure/write/read 权限分别作用的资源：声明队列需要队列的 configure，
+// 发布需要 exchange 的 write，消费需要队列的 read；绑定时还需要队列的 write 和 exchange 的 read
+func rabbitmqPermissionResources(cfg MQConfig) (configure, write, read []string) {
+	queue := rabbitmqQueue(cfg)
+	if cfg.Exchange == "" {
+		return []string{queue}, []string{"amq.default"}, []string{queue}
+	}
+	return []string{queue}, []string{cfg.Exchange, queue}, []string{queue, cfg.Exchange}
+}
+
+// 检查 vhost 是否存在以及用户在 vhost 上的权限
+func checkRabbitMQVhost(client *http.Client, cfg MQConfig, res *RabbitMQManagementResult) error {
+	vhost := rabbitmqVhost(cfg)
+	err := rabbitmqManagementGet(client, cfg, rabbitmqAPIPath("vhosts", vhost), nil)
+	if errors.Is(err, errRabbitMQManagementNotFound) {
+		res.Problems = append(res.Problems, fmt.Sprintf("vhost %s does not exist", vhost))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	res.VhostExists = true
+
+	var perm rabbitmqPermission
+	err = rabbitmqManagementGet(client, cfg, rabbitmqAPIPath("permissions", vhost, cfg.User), &perm)
+	if errors.Is(err, errRabbitMQManagementNotFound) {
+		res.Problems = append(res.Problems, fmt.Sprintf("user %s has no permissions on vhost %s", cfg.User, vhost))
+		return nil
+	}
+	// 查询权限需要 administrator 标签，其他用户只记录原因
+	if err != nil {
+		res.PermissionError = err.Error()
+		return nil
+	}
+	configure, write, read := rabbitmqPermissionResources(cfg)
+	res.Permissions = &RabbitMQPermissions{Configure: perm.Configure, Write: perm.Write, Read: perm.Read}
+	for _, p := range []struct {
+		name      string
+		pattern   string
+		resources []string
+		allowed   *bool
+	}{
+		{"configure", perm.Configure, configure, &res.Permissions.CanConfigure},
+		{"write", perm.Write, write, &res.Permissions.CanWrite},
+		{"read", perm.Read, read, &res.Permissions.CanRead},
+	} {
+		*p.allowed = true
+		for _, resource := range p.resources {
+			allowed, err := rabbitmqPermissionAllows(p.pattern, resource)
+			if err != nil {
+				res.Problems = append(res.Problems, fmt.Sprintf("invalid %s permission pattern %q: %v", p.name, p.pattern, err))
+				*p.allowed = false
+				break
+			}
+			if !allowed {
+				res.Problems = append(res.Problems, fmt.Sprintf("user %s lacks %s permission on %s in vhost %s", cfg.User, p.name, resource, vhost))
+				*p.allowed = false
+			}
+		}
+	}
+	return nil
+}
+
+// 通过管理 API 检查集群健康状态
+func RabbitMQManagementHealth(cfg MQConfig) *RabbitMQManagementResult {
+	res := &RabbitMQManagementResult{Success: "false", URL: cfg.ManagementURL}
+	client, err := newHTTPClient(cfg.ManagementURL, cfg)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	var overview rabbitmqOverview
+	if err := rabbitmqManagementGet(client, cfg, "/api/overview", &overview); err != nil {
+		res.Error = fmt.Sprintf("rabbitmq management error: %v", err)
+		return res
+	}
+	res.ClusterName = overview.ClusterName
+	res.RabbitMQVersion = overview.RabbitMQVersion
+	res.ErlangVersion = overview.ErlangVersion
+	for _, check := range []func(*http.Client, MQConfig, *RabbitMQManagementResult) error{
+		checkRabbitMQNodes,
+		checkRabbitMQAlarms,
+		checkRabbitMQVhost,
+	} {
+		if err := check(client, cfg, res); err != nil {
+			res.Error = fmt.Sprintf("rabbitmq management error: %v", err)
+			return res
+		}
+	}
+	logger.DebugLog("rabbitmq management: cluster=%s version=%s running=%d/%d problems=%v",
+		res.ClusterName, res.RabbitMQVersion, res.RunningNodes, len(res.Nodes), res.Problems)
+	if len(res.Problems) == 0 {
+		res.Success = "true"
+	}
+	return res
+}
@@ -0,0 +1,65 @@
+package verify
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRabbitMQPermissionAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		resource string
+		want     bool
+		wantErr  bool
+	}{
+		{"empty", "", "precheck.q", false, false},
+		{"match all", ".*", "precheck.q", true, false},
+		{"exact", "precheck.q", "precheck.q", true, false},
+		{"anchored prefix", "precheck", "precheck.q", false, false},
+		{"anchored suffix", "q", "precheck.q", false, false},
+		{"prefix wildcard", "^precheck\\..*", "precheck.q", true, false},
+		{"alternation", "amq\\.default|precheck\\..*", "precheck.q", true, false},
+		{"alternation no match", "amq\\.default|other\\..*", "precheck.q", false, false},
+		{"invalid regex", "precheck.(", "precheck.q", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rabbitmqPermissionAllows(tt.pattern, tt.resource)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("rabbitmqPermissionAllows(%q, %q) = %v, %v, want %v, wantErr %v", tt.pattern, tt.resource, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRabbitMQPermissionResources(t *testing.T) {
+	tests := []struct {
+		name                   string
+		cfg                    MQConfig
+		configure, write, read []string
+	}{
+		{
+			name:      "default exchange",
+			cfg:       MQConfig{RunID: "r1"},
+			configure: []string{rabbitmqDefaultQueue},
+			write:     []string{"amq.default"},
+			read:      []string{rabbitmqDefaultQueue},
+		},
+		{
+			name:      "bound to exchange",
+			cfg:       MQConfig{RunID: "r1", Exchange: "orders"},
+			configure: []string{"precheck-r1"},
+			write:     []string{"orders", "precheck-r1"},
+			read:      []string{"precheck-r1", "orders"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configure, write, read := rabbitmqPermissionResources(tt.cfg)
+			if !slices.Equal(configure, tt.configure) || !slices.Equal(write, tt.write) || !slices.Equal(read, tt.read) {
+				t.Errorf("rabbitmqPermissionResources() = %v, %v, %v, want %v, %v, %v", configure, write, read, tt.configure, tt.write, tt.read)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+)
+
+var errRabbitMQManagementNotFound = errors.New("not found")
+
+// 拼接管理 API 路径，每段单独转义，vhost "/" 需转义为 %2F
+func rabbitmqAPIPath(parts ...string) string {
+	escaped := make([]string, 0, len(parts))
+	for _, p := range parts {
+		escaped = append(escaped, url.PathEscape(p))
+	}
+	return "/api/" + strings.Join(escaped, "/")
+}
+
+// 请求管理 API，使用与 AMQP 相同的用户名和密码，返回状态码和响应内容
+func rabbitmqManagementDo(client *http.Client, cfg MQConfig, path string) (int, []byte, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(cfg.ManagementURL, "/")+path, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.SetBasicAuth(cfg.User, cfg.Password)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	logger.DebugLog("rabbitmq management GET %s: %d", path, resp.StatusCode)
+	return resp.StatusCode, body, nil
+}
+
+// 请求管理 API 并解析 JSON 响应
+func rabbitmqManagementGet(client *http.Client, cfg MQConfig, path string, out any) error {
+	status, body, err := rabbitmqManagementDo(client, cfg, path)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNotFound {
+		return fmt.Errorf("GET %s: %w", path, errRabbitMQManagementNotFound)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("GET %s: %d %s", path, status, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+type rabbitmqQueueInfo struct {
+	Type           string          `json:"type"`
+	Policy         string          `json:"policy"`
+	OperatorPolicy string          `json:"operator_policy"`
+	Arguments      map[string]any  `json:"arguments"`
+	Effective      json.RawMessage `json:"effective_policy_definition"`
+}
+
+// 根据生效的策略给出可能导致消息丢失或发布失败的配置提示
+func rabbitmqPolicyWarnings(client *http.Client, cfg MQConfig, def map[string]any) []string {
+	var warnings []string
+	dlx, _ := def["dead-letter-exchange"].(string)
+	if ttl, ok := def["message-ttl"]; ok {
+		warnings = append(warnings, fmt.Sprintf("message-ttl=%vms: unconsumed messages expire", ttl))
+	}
+	if expires, ok := def["expires"]; ok {
+		warnings = append(warnings, fmt.Sprintf("expires=%vms: idle queues are deleted", expires))
+	}
+	_, maxLen := def["max-length"]
+	_, maxBytes := def["max-length-bytes"]
+	if maxLen || maxBytes {
+		overflow, _ := def["overflow"].(string)
+		if overflow == "" {
+			overflow = "drop-head"
+		}
+		warnings = append(warnings, fmt.Sprintf("max-length=%v max-length-bytes=%v overflow=%s", def["max-length"], def["max-length-bytes"], overflow))
+	}
+	_, hasTTL := def["message-ttl"]
+	if dlx == "" && (hasTTL || maxLen || maxBytes) {
+		warnings = append(warnings, "no dead-letter-exchange: expired or dropped messages are discarded")
+	}
+	if dlx != "" {
+		err := rabbitmqManagementGet(client, cfg, rabbitmqAPIPath("exchanges", rabbitmqVhost(cfg), dlx), nil)
+		if errors.Is(err, errRabbitMQManagementNotFound) {
+			warnings = append(warnings, fmt.Sprintf("dead-letter-exchange %s does not exist: dead-lettered messages are discarded", dlx))
+		} else if err != nil {
+			warnings = append(warnings, fmt.Sprintf("check dead-letter-exchange %s error: %v", dlx, err))
+		}
+	}
+	return warnings
+}
+
+// 通过管理 API 查询作用于本次检测队列的策略
+func RabbitMQQueuePolicy(cfg MQConfig) *RabbitMQPolicyResult {
+	res := &RabbitMQPolicyResult{Success: "false", Queue: rabbitmqQueue(cfg)}
+	client, err := newHTTPClient(cfg.ManagementURL, cfg)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	var info rabbitmqQueueInfo
+	path := rabbitmqAPIPath("queues", rabbitmqVhost(cfg), res.Queue)
+	// 管理插件的统计数据有延迟，队列刚声明时可能查询不到
+	for i := 0; i < 3; i++ {
+		err = rabbitmqManagementGet(client, cfg, path, &info)
+		if !errors.Is(err, errRabbitMQManagementNotFound) {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if err != nil {
+		res.Error = fmt.Sprintf("rabbitmq management error: %v", err)
+		return res
+	}
+	res.QueueType = info.Type
+	res.Policy = info.Policy
+	res.OperatorPolicy = info.OperatorPolicy
+	res.Arguments = info.Arguments
+	// 没有策略时部分版本返回空数组
+	_ = json.Unmarshal(info.Effective, &res.Definition)
+	res.Warnings = rabbitmqPolicyWarnings(client, cfg, res.Definition)
+	res.Success = "true"
+	return res
+}
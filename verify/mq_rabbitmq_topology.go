@@ -0,0 +1,186 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"checker-middleware/pkg/logger"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// 未指定 exchange 且使用 classic 队列时沿用默认 exchange 和固定队列
+const rabbitmqDefaultQueue = "laiye_precheck"
+
+var rabbitmqQueueTypes = []string{"classic", "quorum", "stream"}
+
+func rabbitmqQueueType(cfg MQConfig) string {
+	if cfg.QueueType == "" {
+		return "classic"
+	}
+	return strings.ToLower(cfg.QueueType)
+}
+
+// 本次检测使用的队列，指定 exchange 或非 classic 队列时使用以 run ID 命名的临时队列，
+// 避免与已存在的同名队列参数冲突
+func rabbitmqQueue(cfg MQConfig) string {
+	if cfg.Exchange == "" && rabbitmqQueueType(cfg) == "classic" {
+		return rabbitmqDefaultQueue
+	}
+	return "precheck-" + cfg.RunID
+}
+
+// 发布使用的 routing key，默认 exchange 按队列名路由
+func rabbitmqRoutingKey(cfg MQConfig) string {
+	if cfg.Exchange == "" {
+		return rabbitmqQueue(cfg)
+	}
+	if cfg.RoutingKey == "" {
+		return "precheck." + cfg.RunID
+	}
+	return cfg.RoutingKey
+}
+
+// 绑定临时队列使用的 binding key，默认与 routing key 相同
+func rabbitmqBindingKey(cfg MQConfig) string {
+	if cfg.BindingKey == "" {
+		return rabbitmqRoutingKey(cfg)
+	}
+	return cfg.BindingKey
+}
+
+// 按队列类型声明队列，quorum 和 stream 队列必须是持久化的
+func declareRabbitMQQueueOfType(ch *amqp.Channel, cfg MQConfig, queue string) error {
+	kind := rabbitmqQueueType(cfg)
+	if !slices.Contains(rabbitmqQueueTypes, kind) {
+		return fmt.Errorf("unsupported queue type: %s", cfg.QueueType)
+	}
+	durable := queue == rabbitmqDefaultQueue
+	var args amqp.Table
+	if kind != "classic" {
+		durable = true
+		args = amqp.Table{"x-queue-type": kind}
+	}
+	if _, err := ch.QueueDeclare(queue, durable, false, false, false, args); err != nil {
+		return fmt.Errorf("rabbitmq queue error: %v", err)
+	}
+	return nil
+}
+
+// 声明本次检测的队列
+func declareRabbitMQQueue(ch *amqp.Channel, cfg MQConfig, result map[string]string) error {
+	queue := rabbitmqQueue(cfg)
+	result["queue"] = queue
+	result["queue_type"] = rabbitmqQueueType(cfg)
+	return declareRabbitMQQueueOfType(ch, cfg, queue)
+}
+
+func isRabbitMQNotFound(err error) bool {
+	var amqpErr *amqp.Error
+	return errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound
+}
+
+// 被动声明检查 exchange 是否存在，不存在时 broker 会关闭 channel，因此单独使用一个 channel
+func rabbitmqExchangeExists(conn *amqp.Connection, name string) (bool, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return false, fmt.Errorf("rabbitmq channel error: %v", err)
+	}
+	defer ch.Close()
+	err = ch.ExchangeDeclarePassive(name, "", false, false, false, false, nil)
+	if isRabbitMQNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("rabbitmq exchange check error: %v", err)
+	}
+	return true, nil
+}
+
+// 检查或声明 exchange，声明临时队列并绑定，返回 exchange 是否由本工具创建
+func setupRabbitMQTopology(conn *amqp.Connection, cfg MQConfig, result map[string]string) (bool, error) {
+	exists, err := rabbitmqExchangeExists(conn, cfg.Exchange)
+	if err != nil {
+		return false, err
+	}
+	result["exchange"] = cfg.Exchange
+	created := false
+	if !exists {
+		if cfg.ExchangePassive {
+			return false, fmt.Errorf("exchange %s does not exist in vhost %s", cfg.Exchange, rabbitmqVhost(cfg))
+		}
+		kind := strings.ToLower(cfg.ExchangeType)
+		if kind == "" {
+			kind = amqp.ExchangeDirect
+		}
+		ch, err := conn.Channel()
+		if err != nil {
+			return false, fmt.Errorf("rabbitmq channel error: %v", err)
+		}
+		defer ch.Close()
+		if err := ch.ExchangeDeclare(cfg.Exchange, kind, false, false, false, false, nil); err != nil {
+			return false, fmt.Errorf("rabbitmq exchange declare error: %v", err)
+		}
+		result["exchange_type"] = kind
+		created = true
+	}
+	result["exchange_created"] = fmt.Sprintf("%v", created)
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return created, fmt.Errorf("rabbitmq channel error: %v", err)
+	}
+	defer ch.Close()
+	if err := declareRabbitMQQueue(ch, cfg, result); err != nil {
+		return created, err
+	}
+	queue := rabbitmqQueue(cfg)
+	key := rabbitmqBindingKey(cfg)
+	if err := ch.QueueBind(queue, key, cfg.Exchange, false, nil); err != nil {
+		return created, fmt.Errorf("rabbitmq queue bind error: %v", err)
+	}
+	result["binding_key"] = key
+	logger.DebugLog("rabbitmq topology: exchange=%s created=%v queue=%s binding_key=%s", cfg.Exchange, created, queue, key)
+	return created, nil
+}
+
+// 删除本次检测的队列，以及本工具创建的 exchange
+func cleanupRabbitMQ(cfg MQConfig, exchangeCreated bool) map[string]string {
+	result := map[string]string{"success": "false"}
+	conn, err := dialRabbitMQ(cfg)
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	defer conn.Close()
+	queue := rabbitmqQueue(cfg)
+	ch, err := conn.Channel()
+	if err != nil {
+		result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+		return result
+	}
+	// 删除阶段成功时队列已不存在
+	if _, err := ch.QueueDelete(queue, false, false, false); err != nil && !isRabbitMQNotFound(err) {
+		result["error"] = fmt.Sprintf("rabbitmq queue delete error: %v", err)
+		return result
+	}
+	ch.Close()
+	result["queue"] = queue
+	if exchangeCreated {
+		ch, err := conn.Channel()
+		if err != nil {
+			result["error"] = fmt.Sprintf("rabbitmq channel error: %v", err)
+			return result
+		}
+		defer ch.Close()
+		if err := ch.ExchangeDelete(cfg.Exchange, false, false); err != nil {
+			result["error"] = fmt.Sprintf("rabbitmq exchange delete error: %v", err)
+			return result
+		}
+		result["exchange"] = cfg.Exchange
+	}
+	result["success"] = "true"
+	return result
+}
@@ -0,0 +1,179 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+
+	rocketmq "github.com/apache/rocketmq-client-go/v2"
+	"github.com/apache/rocketmq-client-go/v2/admin"
+	"github.com/apache/rocketmq-client-go/v2/consumer"
+	"github.com/apache/rocketmq-client-go/v2/primitive"
+	"github.com/apache/rocketmq-client-go/v2/producer"
+	"github.com/apache/rocketmq-client-go/v2/rlog"
+)
+
+const (
+	rocketmqDefaultProducerGroup = "precheck_producer"
+	rocketmqTag                  = "precheck"
+)
+
+func rocketmqProducerGroup(cfg MQConfig) string {
+	if cfg.ProducerGroup == "" {
+		return rocketmqDefaultProducerGroup
+	}
+	return cfg.ProducerGroup
+}
+
+// 默认每次检测使用新的消费组，ConsumeFromTimestamp 只对没有消费位点的新消费组生效
+func rocketmqConsumerGroup(cfg MQConfig) string {
+	if cfg.ConsumerGroup == "" {
+		return "precheck-" + cfg.RunID
+	}
+	return cfg.ConsumerGroup
+}
+
+// 未开启 ACL 时不设置凭证
+func rocketmqCredentials(cfg MQConfig) primitive.Credentials {
+	if cfg.AccessKey == "" {
+		return primitive.Credentials{}
+	}
+	return primitive.Credentials{AccessKey: cfg.AccessKey, SecretKey: cfg.SecretKey}
+}
+
+// 客户端默认输出大量 info 日志，非 debug 模式只保留错误
+func initRocketMQLog() {
+	if logger.Debug {
+		rlog.SetLogLevel("info")
+		return
+	}
+	rlog.SetLogLevel("error")
+}
+
+// 通过 NameServer 查询 topic 路由，确认 topic 存在并输出可写的 broker 和队列
+func rocketmqConnect(cfg MQConfig, result map[string]string) error {
+	initRocketMQLog()
+	adm, err := admin.NewAdmin(
+		admin.WithResolver(primitive.NewPassthroughResolver(cfg.NameServers)),
+		admin.WithCredentials(rocketmqCredentials(cfg)),
+	)
+	if err != nil {
+		return fmt.Errorf("rocketmq admin error: %v", err)
+	}
+	defer adm.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	queues, err := adm.FetchPublishMessageQueues(ctx, cfg.Topic)
+	if err != nil {
+		return fmt.Errorf("rocketmq route lookup error (topic=%s): %v", cfg.Topic, err)
+	}
+	if len(queues) == 0 {
+		return fmt.Errorf("topic %s has no writable queues", cfg.Topic)
+	}
+	brokers := map[string]bool{}
+	for _, q := range queues {
+		brokers[q.BrokerName] = true
+	}
+	names := make([]string, 0, len(brokers))
+	for name := range brokers {
+		names = append(names, name)
+	}
+	result["brokers"] = strings.Join(names, ",")
+	result["queues"] = fmt.Sprintf("%d", len(queues))
+	logger.DebugLog("rocketmq route: topic=%s brokers=%v queues=%d", cfg.Topic, names, len(queues))
+	return nil
+}
+
+// 同步发送一条以 run ID 为 key 的消息
+func rocketmqWrite(cfg MQConfig, msg string, result map[string]string) error {
+	initRocketMQLog()
+	p, err := rocketmq.NewProducer(
+		producer.WithNsResolver(primitive.NewPassthroughResolver(cfg.NameServers)),
+		producer.WithGroupName(rocketmqProducerGroup(cfg)),
+		producer.WithCredentials(rocketmqCredentials(cfg)),
+		producer.WithRetry(2),
+		producer.WithSendMsgTimeout(10*time.Second),
+	)
+	if err != nil {
+		return fmt.Errorf("rocketmq producer error: %v", err)
+	}
+	if err := p.Start(); err != nil {
+		return fmt.Errorf("rocketmq producer start error: %v", err)
+	}
+	defer p.Shutdown()
+	m := primitive.NewMessage(cfg.Topic, []byte(msg))
+	m.WithKeys([]string{cfg.RunID})
+	m.WithTag(rocketmqTag)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	res, err := p.SendSync(ctx, m)
+	if err != nil {
+		return fmt.Errorf("rocketmq send error: %v", err)
+	}
+	if res.Status != primitive.SendOK {
+		return fmt.Errorf("rocketmq send status: %s", res.String())
+	}
+	result["send_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	result["run_id"] = cfg.RunID
+	result["msg_id"] = res.MsgID
+	result["broker"] = res.MessageQueue.BrokerName
+	result["queue_id"] = fmt.Sprintf("%d", res.MessageQueue.QueueId)
+	result["queue_offset"] = fmt.Sprintf("%d", res.QueueOffset)
+	logger.DebugLog("rocketmq sent: %s", res.String())
+	return nil
+}
+
+// 启动消费者读取本次 run ID 的消息，返回 ConsumeSuccess 即为 ack
+func rocketmqConsume(cfg MQConfig, result map[string]string) error {
+	initRocketMQLog()
+	// 从检测开始前一分钟的位置消费，避免新消费组从最新位点开始时错过本次消息
+	since := time.Now().Add(-time.Minute).Format("20060102150405")
+	c, err := rocketmq.NewPushConsumer(
+		consumer.WithNsResolver(primitive.NewPassthroughResolver(cfg.NameServers)),
+		consumer.WithGroupName(rocketmqConsumerGroup(cfg)),
+		consumer.WithCredentials(rocketmqCredentials(cfg)),
+		consumer.WithConsumeFromWhere(consumer.ConsumeFromTimestamp),
+		consumer.WithConsumeTimestamp(since),
+	)
+	if err != nil {
+		return fmt.Errorf("rocketmq consumer error: %v", err)
+	}
+	found := make(chan *primitive.MessageExt, 1)
+	selector := consumer.MessageSelector{Type: consumer.TAG, Expression: rocketmqTag}
+	err = c.Subscribe(cfg.Topic, selector, func(ctx context.Context, msgs ...*primitive.MessageExt) (consumer.ConsumeResult, error) {
+		for _, m := range msgs {
+			if m.GetKeys() == cfg.RunID {
+				select {
+				case found <- m:
+				default:
+				}
+			}
+		}
+		return consumer.ConsumeSuccess, nil
+	})
+	if err != nil {
+		return fmt.Errorf("rocketmq subscribe error: %v", err)
+	}
+	start := time.Now()
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("rocketmq consumer start error: %v", err)
+	}
+	defer c.Shutdown()
+	// 新消费组需要等待 rebalance 分配队列
+	select {
+	case m := <-found:
+		result["consume_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+		result["msg_id"] = m.MsgId
+		result["queue_id"] = fmt.Sprintf("%d", m.Queue.QueueId)
+		result["queue_offset"] = fmt.Sprintf("%d", m.QueueOffset)
+		result["consumer_group"] = rocketmqConsumerGroup(cfg)
+		return nil
+	case <-time.After(30 * time.Second):
+		return fmt.Errorf("run id %s not consumed within 30s", cfg.RunID)
+	}
+}
@@ -0,0 +1,79 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"checker-middleware/pkg/logger"
+	pkgutil "checker-middleware/pkg/util"
+)
+
+// 消息大小探测从 64KB 开始逐次翻倍，最后一次为目标大小
+const mqSizeProbeStart = 64 * 1024
+
+func mqSizeProbeSizes(target int) []int {
+	var sizes []int
+	for size := mqSizeProbeStart; size < target; size *= 2 {
+		sizes = append(sizes, size)
+	}
+	return append(sizes, target)
+}
+
+// 消息体以 run ID 开头，其余用固定字符填充到指定大小
+func mqSizedPayload(runID string, size int) string {
+	head := fmt.Sprintf("hello %s size %d ", runID, size)
+	if len(head) >= size {
+		return head[:size]
+	}
+	return head + strings.Repeat("x", size-len(head))
+}
+
+// 按常规检测的连接、写入、删除（消费）完成一次往返
+func mqSizeRoundTrip(cfg MQConfig, size int) MQMessageSizeAttempt {
+	attempt := MQMessageSizeAttempt{Size: size, Success: "false"}
+	start := time.Now()
+	stages := []struct {
+		name string
+		run  func() map[string]string
+	}{
+		{"connect", func() map[string]string { return MQConnect(cfg) }},
+		{"write", func() map[string]string { return MQWrite(cfg, mqSizedPayload(cfg.RunID, size)) }},
+		{"delete", func() map[string]string { return MQDelete(cfg) }},
+	}
+	for _, stage := range stages {
+		res := stage.run()
+		if res["success"] == "false" {
+			attempt.Stage = stage.name
+			attempt.Error = res["error"]
+			return attempt
+		}
+		// RabbitMQ 默认队列读不到消息时删除阶段仍然成功，需要确认读到的是本次消息
+		if stage.name == "delete" && strings.ToLower(cfg.Provider) == "rabbitmq" && res["run_id_matched"] != "true" {
+			attempt.Stage = stage.name
+			attempt.Error = "message was not consumed back from the queue"
+			return attempt
+		}
+	}
+	attempt.RoundTripMs = pkgutil.DurationMs(time.Since(start))
+	attempt.Success = "true"
+	return attempt
+}
+
+// 逐步增大消息体直到 MaxMessageSize，记录往返成功的最大大小以及首次失败时 broker 返回的错误
+func MQMessageSizeProbe(cfg MQConfig) *MQMessageSizeResult {
+	res := &MQMessageSizeResult{Success: "false", Target: cfg.MaxMessageSize}
+	for _, size := range mqSizeProbeSizes(cfg.MaxMessageSize) {
+		attempt := mqSizeRoundTrip(cfg, size)
+		res.Attempts = append(res.Attempts, attempt)
+		logger.DebugLog("mq message size probe: size=%d success=%s stage=%s error=%s", size, attempt.Success, attempt.Stage, attempt.Error)
+		if attempt.Success != "true" {
+			res.FailedSize = size
+			res.Error = fmt.Sprintf("%s failed at %d bytes: %s", attempt.Stage, size, attempt.Error)
+			return res
+		}
+		res.MaxSucceeded = size
+	}
+	res.Success = "true"
+	return res
+}
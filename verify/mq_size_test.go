@@ -0,0 +1,34 @@
+package verify
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMQSizeProbeSizes(t *testing.T) {
+	const kb = 1024
+	tests := []struct {
+		target int
+		want   []int
+	}{
+		{1 * kb, []int{1 * kb}},
+		{64 * kb, []int{64 * kb}},
+		{100 * kb, []int{64 * kb, 100 * kb}},
+		{128 * kb, []int{64 * kb, 128 * kb}},
+		{1024 * kb, []int{64 * kb, 128 * kb, 256 * kb, 512 * kb, 1024 * kb}},
+		{1000 * kb, []int{64 * kb, 128 * kb, 256 * kb, 512 * kb, 1000 * kb}},
+	}
+	for _, tt := range tests {
+		if got := mqSizeProbeSizes(tt.target); !slices.Equal(got, tt.want) {
+			t.Errorf("mqSizeProbeSizes(%d) = %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestMQSizedPayload(t *testing.T) {
+	for _, size := range []int{1, 10, 64 * 1024} {
+		if got := mqSizedPayload("run", size); len(got) != size {
+			t.Errorf("mqSizedPayload(%d) length = %d", size, len(got))
+		}
+	}
+}
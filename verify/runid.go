@@ -0,0 +1,15 @@
+package verify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// 生成本次检测的唯一ID，用于关联生产和消费的消息，以及命名临时的队列、topic 和对象
+func newRunID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%d-%s", time.Now().UnixNano(), hex.EncodeToString(b))
+}
@@ -3,317 +3,268 @@ package verify
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"checker-middleware/pkg/logger"
 	pkgutil "checker-middleware/pkg/util"
 	"strings"
 	"time"
+)
 
-	"github.com/aliyun/aliyun-oss-go-sdk/oss"
-
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-
-	awsCreds "github.com/aws/aws-sdk-go-v2/credentials"
-	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
+const (
+	storageContentType = "application/octet-stream"
+	// 测试对象的大小，随机内容避免网关或缓存返回旧对象
+	storagePayloadSize = 64 * 1024
+	// 上传时写入的自定义元数据，HEAD 时比对
+	storageSHA256Meta = "precheck-sha256"
 )
 
-// 连通性测试
-func StorageConnect(cfg StorageConfig) map[string]string {
-	result := map[string]string{"success": "false"}
-	provider := strings.ToLower(cfg.Provider)
-	switch provider {
+// 对象存储客户端，每次检测只创建一次并在各阶段复用
+type StorageClient interface {
+	Name() string // 错误信息中使用的名称
+	CheckBucket(ctx context.Context) error
+	// 带 Content-MD5 上传，返回服务端的 ETag
+	PutObject(ctx context.Context, key string, data []byte, meta map[string]string) (string, error)
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	HeadObject(ctx context.Context, key string) (StorageObjectInfo, error)
+	DeleteObject(ctx context.Context, key string) error
+	IsNotFound(err error) bool // 对象不存在(404)
+}
+
+// 按存储类型创建客户端
+func NewStorageClient(cfg StorageConfig) (StorageClient, error) {
+	switch strings.ToLower(cfg.Provider) {
 	case "minio":
-		client, err := minio.New(cfg.Endpoint, &minio.Options{
-			Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
-			Secure: cfg.Secure,
-		})
-		if err != nil {
-			result["error"] = fmt.Sprintf("minio connect error: %v", err)
-			logger.DebugLog("minio connect error: %v", err)
-			return result
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
-		defer cancel()
-		exists, err := client.BucketExists(ctx, cfg.Bucket)
-		if err != nil || !exists {
-			result["error"] = fmt.Sprintln("Bucket not exists")
-			logger.DebugLog("Bucket not exists")
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("minio connect success")
+		return newMinioStorage(cfg)
 	case "oss":
-		client, err := oss.New(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey)
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss connect error: %v", err)
-			logger.DebugLog("oss connect error: %v", err)
-			return result
-		}
-		bucket, err := client.Bucket(cfg.Bucket)
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss bucket error: %v", err)
-			logger.DebugLog("oss bucket error: %v", err)
-			return result
-		}
-		_, _ = bucket.GetObjectMeta("not_exist_key")
-		// 只要能正常请求即可
-		result["success"] = "true"
-		logger.DebugLog("oss connect success")
+		return newOSSStorage(cfg)
 	case "s3":
-		endpoint := pkgutil.TrimProtocol(cfg.Endpoint)
-		protocol := "http://"
-		if cfg.Secure {
-			protocol = "https://"
-		}
-		awsCfg, err := config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(cfg.Region),
-			config.WithCredentialsProvider(awsCreds.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
-			config.WithEndpointResolver(
-				aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
-					return aws.Endpoint{
-						URL:               protocol + endpoint,
-						SigningRegion:     cfg.Region,
-						HostnameImmutable: true,
-					}, nil
-				}),
-			),
-		)
-		if err != nil {
-			result["error"] = fmt.Sprintf("s3 v2 config error: %v", err)
-			logger.DebugLog("s3 v2 config error: %v", err)
-			return result
-		}
-		client := awsS3.NewFromConfig(awsCfg, func(o *awsS3.Options) {
-			o.UsePathStyle = cfg.UsePathStyle
-		})
-		ctx, cancel := context.WithTimeout(
-			context.Background(),
-			time.Duration(cfg.Timeout)*time.Second,
-		)
-		defer cancel()
-		_, err = client.HeadBucket(ctx, &awsS3.HeadBucketInput{
-			Bucket: &cfg.Bucket,
-		})
-		if err != nil {
-			result["error"] = fmt.Sprintf("s3 v2 bucket error: %v", err)
-			logger.DebugLog("s3 v2 bucket error: %v", err)
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("s3 v2 connect success")
-	// ...existing code...
+		return newS3Storage(cfg)
 	default:
-		result["error"] = "unsupported provider"
+		return nil, fmt.Errorf("unsupported provider")
 	}
+}
+
+func storageContext(cfg StorageConfig) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+}
+
+func storageContentMD5(data []byte) string {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func storageSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func storageMD5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func storagePayload() []byte {
+	data := make([]byte, storagePayloadSize)
+	_, _ = rand.Read(data)
+	return data
+}
+
+// 单次上传且未使用 KMS 加密时 ETag 为内容的 MD5，分片上传等情况下无法比对
+func storageETagMD5(etag string) (string, bool) {
+	etag = strings.ToLower(strings.Trim(etag, `"`))
+	if len(etag) != 32 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(etag); err != nil {
+		return "", false
+	}
+	return etag, true
+}
+
+// 比对对象内容，返回不一致的项
+func storageCompareContent(data, expected []byte, etag string, result map[string]string) []string {
+	var mismatches []string
+	result["size"] = fmt.Sprintf("%d", len(data))
+	if len(data) != len(expected) {
+		mismatches = append(mismatches, fmt.Sprintf("size %d != %d", len(data), len(expected)))
+	}
+	result["md5"] = storageMD5Hex(data)
+	if md5Hex, ok := storageETagMD5(etag); ok {
+		result["etag_md5_match"] = fmt.Sprintf("%v", md5Hex == result["md5"])
+		if md5Hex != result["md5"] {
+			mismatches = append(mismatches, fmt.Sprintf("md5 %s != etag %s", result["md5"], etag))
+		}
+	} else {
+		result["etag_md5_match"] = "skip"
+	}
+	result["sha256"] = storageSHA256(data)
+	if !bytes.Equal(data, expected) {
+		mismatches = append(mismatches, fmt.Sprintf("sha256 %s != %s", result["sha256"], storageSHA256(expected)))
+	}
+	return mismatches
+}
+
+// 连通性测试
+func StorageConnect(client StorageClient, cfg StorageConfig) map[string]string {
+	result := map[string]string{"success": "false"}
+	ctx, cancel := storageContext(cfg)
+	defer cancel()
+	start := time.Now()
+	if err := client.CheckBucket(ctx); err != nil {
+		result["error"] = fmt.Sprintf("%s bucket error: %v", client.Name(), err)
+		logger.DebugLog("%s bucket error: %v", client.Name(), err)
+		return result
+	}
+	result["connect_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	result["success"] = "true"
+	logger.DebugLog("%s connect success", client.Name())
 	return result
 }
 
-// 上传测试
-func StorageWrite(cfg StorageConfig, objectName, content string) map[string]string {
+// 上传测试，同时写入内容的 SHA-256 元数据
+func StorageWrite(client StorageClient, cfg StorageConfig, objectName string, content []byte) map[string]string {
 	result := map[string]string{"success": "false"}
-	provider := strings.ToLower(cfg.Provider)
-	switch provider {
-	case "minio":
-		client, err := minio.New(cfg.Endpoint, &minio.Options{
-			Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
-			Secure: cfg.Secure,
-		})
-		if err != nil {
-			result["error"] = fmt.Sprintf("minio connect error: %v", err)
-			logger.DebugLog("minio connect error: %v", err)
-			return result
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
-		defer cancel()
-		_, err = client.PutObject(ctx, cfg.Bucket, objectName, bytes.NewReader([]byte(content)), int64(len(content)), minio.PutObjectOptions{})
-		if err != nil {
-			result["error"] = fmt.Sprintf("minio upload error: %v", err)
-			logger.DebugLog("minio upload error: %v", err)
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("minio upload success")
-	case "oss":
-		client, err := oss.New(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey)
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss connect error: %v", err)
-			logger.DebugLog("oss connect error: %v", err)
-			return result
-		}
-		bucket, err := client.Bucket(cfg.Bucket)
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss bucket error: %v", err)
-			logger.DebugLog("oss bucket error: %v", err)
-			return result
-		}
-		err = bucket.PutObject(objectName, bytes.NewReader([]byte(content)))
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss upload error: %v", err)
-			logger.DebugLog("oss upload error: %v", err)
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("oss upload success")
-	case "s3":
-		endpoint := pkgutil.TrimProtocol(cfg.Endpoint)
-		protocol := "http://"
-		if cfg.Secure {
-			protocol = "https://"
-		}
-		awsCfg, err := config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(cfg.Region),
-			config.WithCredentialsProvider(awsCreds.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
-			config.WithEndpointResolver(
-				aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
-					return aws.Endpoint{
-						URL:               protocol + endpoint,
-						SigningRegion:     cfg.Region,
-						HostnameImmutable: true,
-					}, nil
-				}),
-			),
-		)
-		if err != nil {
-			result["error"] = fmt.Sprintf("s3 v2 config error: %v", err)
-			logger.DebugLog("s3 v2 config error: %v", err)
-			return result
-		}
-		client := awsS3.NewFromConfig(awsCfg, func(o *awsS3.Options) {
-			o.UsePathStyle = cfg.UsePathStyle
-		})
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
-		defer cancel()
-		_, err = client.PutObject(ctx, &awsS3.PutObjectInput{
-			Bucket: &cfg.Bucket,
-			Key:    &objectName,
-			Body:   bytes.NewReader([]byte(content)),
-		})
-		if err != nil {
-			result["error"] = fmt.Sprintf("s3 v2 upload error: %v", err)
-			logger.DebugLog("s3 v2 upload error: %v", err)
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("s3 v2 upload success")
-	// ...existing code...
-	default:
-		result["error"] = "unsupported provider"
+	ctx, cancel := storageContext(cfg)
+	defer cancel()
+	start := time.Now()
+	etag, err := client.PutObject(ctx, objectName, content, map[string]string{storageSHA256Meta: storageSHA256(content)})
+	if err != nil {
+		result["error"] = fmt.Sprintf("%s upload error: %v", client.Name(), err)
+		logger.DebugLog("%s upload error: %v", client.Name(), err)
+		return result
 	}
+	result["write_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	result["key"] = objectName
+	result["size"] = fmt.Sprintf("%d", len(content))
+	result["etag"] = etag
+	result["success"] = "true"
+	logger.DebugLog("%s upload success: etag=%s", client.Name(), etag)
 	return result
 }
 
-// 删除测试
-func StorageDelete(cfg StorageConfig, objectName string) map[string]string {
+// HEAD 对象，比对大小、ETag、Content-Type 以及上传时写入的 SHA-256 元数据
+func StorageHead(client StorageClient, cfg StorageConfig, objectName string, content []byte, etag string) map[string]string {
 	result := map[string]string{"success": "false"}
-	provider := strings.ToLower(cfg.Provider)
-	switch provider {
-	case "minio":
-		client, err := minio.New(cfg.Endpoint, &minio.Options{
-			Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
-			Secure: cfg.Secure,
-		})
-		if err != nil {
-			result["error"] = fmt.Sprintf("minio connect error: %v", err)
-			logger.DebugLog("minio connect error: %v", err)
-			return result
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
-		defer cancel()
-		err = client.RemoveObject(ctx, cfg.Bucket, objectName, minio.RemoveObjectOptions{})
-		if err != nil {
-			result["error"] = fmt.Sprintf("minio delete error: %v", err)
-			logger.DebugLog("minio delete error: %v", err)
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("minio delete success")
-	case "oss":
-		client, err := oss.New(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey)
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss connect error: %v", err)
-			logger.DebugLog("oss connect error: %v", err)
-			return result
-		}
-		bucket, err := client.Bucket(cfg.Bucket)
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss bucket error: %v", err)
-			logger.DebugLog("oss bucket error: %v", err)
-			return result
-		}
-		err = bucket.DeleteObject(objectName)
-		if err != nil {
-			result["error"] = fmt.Sprintf("oss delete error: %v", err)
-			logger.DebugLog("oss delete error: %v", err)
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("oss delete success")
-	case "s3":
-		endpoint := pkgutil.TrimProtocol(cfg.Endpoint)
-		protocol := "http://"
-		if cfg.Secure {
-			protocol = "https://"
-		}
-		awsCfg, err := config.LoadDefaultConfig(context.TODO(),
-			config.WithRegion(cfg.Region),
-			config.WithCredentialsProvider(awsCreds.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
-			config.WithEndpointResolver(
-				aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
-					return aws.Endpoint{
-						URL:               protocol + endpoint,
-						SigningRegion:     cfg.Region,
-						HostnameImmutable: true,
-					}, nil
-				}),
-			),
-		)
+	ctx, cancel := storageContext(cfg)
+	defer cancel()
+	start := time.Now()
+	info, err := client.HeadObject(ctx, objectName)
+	if err != nil {
+		result["error"] = fmt.Sprintf("%s head error: %v", client.Name(), err)
+		logger.DebugLog("%s head error: %v", client.Name(), err)
+		return result
+	}
+	result["head_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	result["size"] = fmt.Sprintf("%d", info.Size)
+	result["etag"] = info.ETag
+	result["content_type"] = info.ContentType
+	var mismatches []string
+	if info.Size != int64(len(content)) {
+		mismatches = append(mismatches, fmt.Sprintf("size %d != %d", info.Size, len(content)))
+	}
+	if info.ETag != etag {
+		mismatches = append(mismatches, fmt.Sprintf("etag %s != upload etag %s", info.ETag, etag))
+	}
+	if info.ContentType != storageContentType {
+		mismatches = append(mismatches, fmt.Sprintf("content type %s != %s", info.ContentType, storageContentType))
+	}
+	if sum := storageSHA256(content); info.Metadata[storageSHA256Meta] != sum {
+		mismatches = append(mismatches, fmt.Sprintf("%s metadata %q != %s", storageSHA256Meta, info.Metadata[storageSHA256Meta], sum))
+	}
+	if len(mismatches) > 0 {
+		result["error"] = "metadata mismatch: " + strings.Join(mismatches, "; ")
+		logger.DebugLog("%s head mismatch: %v", client.Name(), mismatches)
+		return result
+	}
+	result["success"] = "true"
+	logger.DebugLog("%s head success", client.Name())
+	return result
+}
 
-		if err != nil {
-			result["error"] = fmt.Sprintf("s3 v2 config error: %v", err)
-			logger.DebugLog("s3 v2 config error: %v", err)
-			return result
-		}
-		client := awsS3.NewFromConfig(awsCfg, func(o *awsS3.Options) {
-			o.UsePathStyle = cfg.UsePathStyle
-		})
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
-		defer cancel()
-		_, err = client.DeleteObject(ctx, &awsS3.DeleteObjectInput{
-			Bucket: &cfg.Bucket,
-			Key:    &objectName,
-		})
-		if err != nil {
-			result["error"] = fmt.Sprintf("s3 v2 delete error: %v", err)
-			logger.DebugLog("s3 v2 delete error: %v", err)
-			return result
-		}
-		result["success"] = "true"
-		logger.DebugLog("s3 v2 delete success")
-	// ...existing code...
-	default:
-		result["error"] = "unsupported provider"
+// 下载对象，比对大小、MD5(ETag) 和 SHA-256
+func StorageRead(client StorageClient, cfg StorageConfig, objectName string, content []byte, etag string) map[string]string {
+	result := map[string]string{"success": "false"}
+	ctx, cancel := storageContext(cfg)
+	defer cancel()
+	start := time.Now()
+	data, err := client.GetObject(ctx, objectName)
+	if err != nil {
+		result["error"] = fmt.Sprintf("%s download error: %v", client.Name(), err)
+		logger.DebugLog("%s download error: %v", client.Name(), err)
+		return result
+	}
+	result["read_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	if mismatches := storageCompareContent(data, content, etag, result); len(mismatches) > 0 {
+		result["error"] = "content mismatch: " + strings.Join(mismatches, "; ")
+		logger.DebugLog("%s read mismatch: %v", client.Name(), mismatches)
+		return result
+	}
+	result["success"] = "true"
+	logger.DebugLog("%s download success", client.Name())
+	return result
+}
+
+// 删除测试
+func StorageDelete(client StorageClient, cfg StorageConfig, objectName string) map[string]string {
+	result := map[string]string{"success": "false"}
+	ctx, cancel := storageContext(cfg)
+	defer cancel()
+	start := time.Now()
+	if err := client.DeleteObject(ctx, objectName); err != nil {
+		result["error"] = fmt.Sprintf("%s delete error: %v", client.Name(), err)
+		logger.DebugLog("%s delete error: %v", client.Name(), err)
+		return result
 	}
+	result["delete_ms"] = fmt.Sprintf("%.3f", pkgutil.DurationMs(time.Since(start)))
+	// 删除后 HEAD 应返回 404，避免删除请求成功但对象仍可访问
+	_, err := client.HeadObject(ctx, objectName)
+	switch {
+	case err == nil:
+		result["error"] = fmt.Sprintf("%s object %s still exists after delete", client.Name(), objectName)
+		logger.DebugLog("%s object still exists after delete", client.Name())
+		return result
+	case !client.IsNotFound(err):
+		result["error"] = fmt.Sprintf("%s head after delete error: %v", client.Name(), err)
+		logger.DebugLog("%s head after delete error: %v", client.Name(), err)
+		return result
+	}
+	result["not_found"] = "true"
+	result["success"] = "true"
+	logger.DebugLog("%s delete success", client.Name())
 	return result
 }
 
 // 一键检测
 func VerifyStorage(cfg StorageConfig) StorageResult {
-	objectName := "precheck_test_object.txt"
-	content := "hello precheck"
+	// 每次检测使用不同的对象，避免并发检测互相覆盖或误删业务对象
+	objectName := "precheck-" + newRunID() + ".bin"
+	content := storagePayload()
 	res := StorageResult{
-		Connect: StorageConnect(cfg),
+		Connect: map[string]string{"success": "false"},
 		Write:   map[string]string{"success": "skip"},
+		Head:    map[string]string{"success": "skip"},
+		Read:    map[string]string{"success": "skip"},
 		Delete:  map[string]string{"success": "skip"},
 	}
-	res.Write = StorageWrite(cfg, objectName, content)
+	client, err := NewStorageClient(cfg)
+	if err != nil {
+		res.Connect["error"] = err.Error()
+		logger.DebugLog("%v", err)
+		return res
+	}
+	res.Connect = StorageConnect(client, cfg)
 	if res.Connect["success"] == "true" {
-		res.Write = StorageWrite(cfg, objectName, content)
+		res.Write = StorageWrite(client, cfg, objectName, content)
 		if res.Write["success"] == "true" {
-			res.Delete = StorageDelete(cfg, objectName)
+			res.Head = StorageHead(client, cfg, objectName, content, res.Write["etag"])
+			res.Read = StorageRead(client, cfg, objectName, content, res.Write["etag"])
+			res.Delete = StorageDelete(client, cfg, objectName)
 		}
 	}
 	return res
@@ -0,0 +1,85 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type minioStorage struct {
+	client *minio.Client
+	bucket string
+}
+
+func newMinioStorage(cfg StorageConfig) (StorageClient, error) {
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.Secure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("minio connect error: %v", err)
+	}
+	return &minioStorage{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *minioStorage) Name() string {
+	return "minio"
+}
+
+func (s *minioStorage) CheckBucket(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket %s not exists", s.bucket)
+	}
+	return nil
+}
+
+func (s *minioStorage) PutObject(ctx context.Context, key string, data []byte, meta map[string]string) (string, error) {
+	info, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:    storageContentType,
+		UserMetadata:   meta,
+		SendContentMd5: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	return info.ETag, nil
+}
+
+func (s *minioStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	return io.ReadAll(obj)
+}
+
+func (s *minioStorage) HeadObject(ctx context.Context, key string) (StorageObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return StorageObjectInfo{}, err
+	}
+	meta := make(map[string]string, len(info.UserMetadata))
+	for k, v := range info.UserMetadata {
+		meta[strings.ToLower(k)] = v
+	}
+	return StorageObjectInfo{Size: info.Size, ETag: info.ETag, ContentType: info.ContentType, Metadata: meta}, nil
+}
+
+func (s *minioStorage) DeleteObject(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *minioStorage) IsNotFound(err error) bool {
+	return minio.ToErrorResponse(err).StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,95 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+const ossMetaPrefix = "x-oss-meta-"
+
+type ossStorage struct {
+	bucket *oss.Bucket
+}
+
+func newOSSStorage(cfg StorageConfig) (StorageClient, error) {
+	client, err := oss.New(cfg.Endpoint, cfg.AccessKey, cfg.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("oss connect error: %v", err)
+	}
+	bucket, err := client.Bucket(cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("oss bucket error: %v", err)
+	}
+	return &ossStorage{bucket: bucket}, nil
+}
+
+func (s *ossStorage) Name() string {
+	return "oss"
+}
+
+// bucket 不存在、无权限或网络错误时返回错误
+func (s *ossStorage) CheckBucket(ctx context.Context) error {
+	_, err := s.bucket.Client.GetBucketInfo(s.bucket.BucketName, oss.WithContext(ctx))
+	return err
+}
+
+func (s *ossStorage) PutObject(ctx context.Context, key string, data []byte, meta map[string]string) (string, error) {
+	var header http.Header
+	options := []oss.Option{
+		oss.WithContext(ctx),
+		oss.ContentType(storageContentType),
+		oss.ContentMD5(storageContentMD5(data)),
+		oss.GetResponseHeader(&header),
+	}
+	for k, v := range meta {
+		options = append(options, oss.Meta(k, v))
+	}
+	if err := s.bucket.PutObject(key, bytes.NewReader(data), options...); err != nil {
+		return "", err
+	}
+	return header.Get("ETag"), nil
+}
+
+func (s *ossStorage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	body, err := s.bucket.GetObject(key, oss.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func (s *ossStorage) HeadObject(ctx context.Context, key string) (StorageObjectInfo, error) {
+	header, err := s.bucket.GetObjectDetailedMeta(key, oss.WithContext(ctx))
+	if err != nil {
+		return StorageObjectInfo{}, err
+	}
+	size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return StorageObjectInfo{}, fmt.Errorf("invalid Content-Length %q: %v", header.Get("Content-Length"), err)
+	}
+	meta := map[string]string{}
+	for k := range header {
+		if name := strings.ToLower(k); strings.HasPrefix(name, ossMetaPrefix) {
+			meta[strings.TrimPrefix(name, ossMetaPrefix)] = header.Get(k)
+		}
+	}
+	return StorageObjectInfo{Size: size, ETag: header.Get("ETag"), ContentType: header.Get("Content-Type"), Metadata: meta}, nil
+}
+
+func (s *ossStorage) DeleteObject(ctx context.Context, key string) error {
+	return s.bucket.DeleteObject(key, oss.WithContext(ctx))
+}
+
+func (s *ossStorage) IsNotFound(err error) bool {
+	var se oss.ServiceError
+	return errors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,125 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	pkgutil "checker-middleware/pkg/util"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsCreds "github.com/aws/aws-sdk-go-v2/credentials"
+	awsS3 "github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type s3Storage struct {
+	client *awsS3.Client
+	bucket string
+}
+
+func newS3Storage(cfg StorageConfig) (StorageClient, error) {
+	endpoint := pkgutil.TrimProtocol(cfg.Endpoint)
+	protocol := "http://"
+	if cfg.Secure {
+		protocol = "https://"
+	}
+	awsCfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithRegion(cfg.Region),
+		config.WithCredentialsProvider(awsCreds.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
+		config.WithEndpointResolver(
+			aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+				return aws.Endpoint{
+					URL:               protocol + endpoint,
+					SigningRegion:     cfg.Region,
+					HostnameImmutable: true,
+				}, nil
+			}),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("s3 v2 config error: %v", err)
+	}
+	client := awsS3.NewFromConfig(awsCfg, func(o *awsS3.Options) {
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+	return &s3Storage{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *s3Storage) Name() string {
+	return "s3 v2"
+}
+
+func (s *s3Storage) CheckBucket(ctx context.Context) error {
+	_, err := s.client.HeadBucket(ctx, &awsS3.HeadBucketInput{
+		Bucket: &s.bucket,
+	})
+	return err
+}
+
+func (s *s3Storage) PutObject(ctx context.Context, key string, data []byte, meta map[string]string) (string, error) {
+	out, err := s.client.PutObject(ctx, &awsS3.PutObjectInput{
+		Bucket:        &s.bucket,
+		Key:           &key,
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(storageContentType),
+		ContentMD5:    aws.String(storageContentMD5(data)),
+		Metadata:      meta,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.ETag), nil
+}
+
+func (s *s3Storage) GetObject(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &awsS3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *s3Storage) HeadObject(ctx context.Context, key string) (StorageObjectInfo, error) {
+	out, err := s.client.HeadObject(ctx, &awsS3.HeadObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return StorageObjectInfo{}, err
+	}
+	meta := make(map[string]string, len(out.Metadata))
+	for k, v := range out.Metadata {
+		meta[strings.ToLower(k)] = v
+	}
+	return StorageObjectInfo{
+		Size:        aws.ToInt64(out.ContentLength),
+		ETag:        aws.ToString(out.ETag),
+		ContentType: aws.ToString(out.ContentType),
+		Metadata:    meta,
+	}, nil
+}
+
+func (s *s3Storage) DeleteObject(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &awsS3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	return err
+}
+
+func (s *s3Storage) IsNotFound(err error) bool {
+	var re *awshttp.ResponseError
+	return errors.As(err, &re) && re.HTTPStatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,61 @@
+package verify
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStorageETagMD5(t *testing.T) {
+	tests := []struct {
+		etag   string
+		want   string
+		wantOK bool
+	}{
+		{`"9E107D9D372BB6826BD81D3542A419D6"`, "9e107d9d372bb6826bd81d3542a419d6", true},
+		{"9e107d9d372bb6826bd81d3542a419d6", "9e107d9d372bb6826bd81d3542a419d6", true},
+		{`"9e107d9d372bb6826bd81d3542a419d6-2"`, "", false}, // 分片上传
+		{`"9e107d9d372bb6826bd81d3542a419zz"`, "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := storageETagMD5(tt.etag)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("storageETagMD5(%q) = %q, %v, want %q, %v", tt.etag, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestStorageCompareContent(t *testing.T) {
+	content := []byte("precheck content")
+	etag := `"` + storageMD5Hex(content) + `"`
+	changed := bytes.Clone(content)
+	changed[0] = 'P'
+	tests := []struct {
+		name           string
+		data           []byte
+		etag           string
+		wantMismatches int
+		wantETagMatch  string
+	}{
+		{"match", content, etag, 0, "true"},
+		{"multipart etag", content, `"abc-2"`, 0, "skip"},
+		{"content changed", changed, etag, 2, "false"},
+		{"content changed without etag", changed, "", 1, "skip"},
+		{"truncated", content[:4], etag, 3, "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := map[string]string{}
+			mismatches := storageCompareContent(tt.data, content, tt.etag, result)
+			if len(mismatches) != tt.wantMismatches {
+				t.Errorf("mismatches = %v, want %d", mismatches, tt.wantMismatches)
+			}
+			if result["etag_md5_match"] != tt.wantETagMatch {
+				t.Errorf("etag_md5_match = %q, want %q", result["etag_md5_match"], tt.wantETagMatch)
+			}
+			if result["md5"] != storageMD5Hex(tt.data) || result["sha256"] != storageSHA256(tt.data) {
+				t.Errorf("md5/sha256 not computed from downloaded data: %v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package verify
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// 根据证书文件构造 TLS 配置，caFile 为空时使用系统根证书，certFile/keyFile 同时提供时启用客户端证书
+func newTLSConfig(caFile, certFile, keyFile string, skipVerify bool) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: skipVerify,
+	}
+	if caFile != "" {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file error: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no valid certificate in ca file %s", caFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be provided together")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate error: %v", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// 管理接口(RabbitMQ 管理 API、ActiveMQ Jolokia 等)共用的 HTTP 客户端，https 地址使用 --tls-* 参数
+func newHTTPClient(rawURL string, cfg MQConfig) (*http.Client, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if strings.HasPrefix(strings.ToLower(rawURL), "https://") {
+		tlsCfg, err := newTLSConfig(cfg.TLSCAFile, cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		client.Transport = &http.Transport{TLSClientConfig: tlsCfg}
+	}
+	return client, nil
+}
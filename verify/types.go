@@ -35,6 +35,7 @@ type CacheConfig struct {
 	Sentinels []string // sentinel 地址列表
 	Master    string   // sentinel 主名
 	Timeout   int      `json:"timeout"`
+	PoolSize  int      // 连接池大小，0 使用默认值
 }
 
 type CacheResult struct {
@@ -43,6 +44,65 @@ type CacheResult struct {
 	Delete  map[string]string `json:"delete"`
 }
 
+type CacheBenchConfig struct {
+	Duration     int            // 压测时长(秒)
+	Clients      int            // 并发客户端数
+	Mix          map[string]int // 操作权重: get/set/pipeline
+	PipelineSize int            // 每个 pipeline 包含的命令数
+	ValueSize    int            // SET 的 value 字节数
+	Keys         int            // 压测使用的 key 数量
+}
+
+type LatencyStats struct {
+	Ops       int64   `json:"ops"`
+	Errors    int64   `json:"errors"`
+	OpsPerSec float64 `json:"ops_per_sec"`
+	P50Ms     float64 `json:"p50_ms"`
+	P95Ms     float64 `json:"p95_ms"`
+	P99Ms     float64 `json:"p99_ms"`
+}
+
+type CacheBenchResult struct {
+	Connect    map[string]string       `json:"connect"`
+	Bench      map[string]string       `json:"bench"`
+	Operations map[string]LatencyStats `json:"operations,omitempty"`
+	Total      *LatencyStats           `json:"total,omitempty"`
+	Cleanup    map[string]string       `json:"cleanup"`
+}
+
+type CacheDiagnoseConfig struct {
+	SlowlogCount    int   // SLOWLOG GET 条数
+	ScanBudget      int   // 大key采样最多扫描的key数量
+	ScanCount       int   // 每次 SCAN 的 COUNT 提示
+	ScanSleepMs     int   // 每批 SCAN 之间的间隔(毫秒)
+	BigKeyTop       int   // 输出的大key数量
+	BigKeyThreshold int64 // 大key告警阈值(字节)
+}
+
+type SlowlogEntry struct {
+	ID         int64   `json:"id"`
+	Time       string  `json:"time"`
+	DurationMs float64 `json:"duration_ms"`
+	Command    string  `json:"command"`
+	Client     string  `json:"client,omitempty"`
+}
+
+type BigKey struct {
+	Key    string `json:"key"`
+	Type   string `json:"type"`
+	Bytes  int64  `json:"bytes"`
+	Length int64  `json:"length"`
+}
+
+type CacheDiagnoseResult struct {
+	Connect  map[string]string `json:"connect"`
+	Slowlog  map[string]any    `json:"slowlog"`
+	Latency  map[string]any    `json:"latency"`
+	Memory   map[string]any    `json:"memory"`
+	BigKeys  map[string]any    `json:"bigkeys"`
+	Findings []string          `json:"findings"`
+}
+
 type StorageConfig struct {
 	Endpoint     string
 	AccessKey    string
@@ -58,24 +118,271 @@ type StorageConfig struct {
 type StorageResult struct {
 	Connect map[string]string `json:"connect"`
 	Write   map[string]string `json:"write"`
+	Head    map[string]string `json:"head"`
+	Read    map[string]string `json:"read"`
 	Delete  map[string]string `json:"delete"`
 }
 
+// HEAD 返回的对象元数据，Metadata 的 key 统一为小写且不带 x-amz-meta-/x-oss-meta- 前缀
+type StorageObjectInfo struct {
+	Size        int64
+	ETag        string
+	ContentType string
+	Metadata    map[string]string
+}
+
 type MQConfig struct {
-	Provider string // "kafka" or "rabbitmq"
+	Provider string // kafka, rabbitmq, rocketmq, pulsar, nats, mqtt, activemq
+	RunID    string // 本次检测的唯一ID，为空时自动生成
+	// 大于 0 时在常规检测后探测消息大小上限（字节）
+	MaxMessageSize int
 	// Kafka
-	Brokers []string
-	Topic   string
+	Brokers       []string
+	Topic         string
+	SASLMechanism string // plain, scram-sha-256, scram-sha-512
+	SASLUser      string
+	SASLPassword  string
+	// topic 不存在时是否只校验不创建，以及创建时的分区数和副本数
+	TopicVerifyOnly   bool
+	Partitions        int
+	ReplicationFactor int
+	// 消费组 lag 检查
+	Groups       []string
+	LagThreshold int64 // 总 lag 超过该值时判定失败，小于 0 表示不限制
+	// 生产保障：acks 级别(all/1/0)，以及是否探测幂等生产和事务
+	RequiredAcks string
+	Idempotence  bool
+	Transactions bool
+	// RocketMQ，Topic 与 Kafka 共用
+	NameServers   []string
+	ProducerGroup string
+	ConsumerGroup string
+	AccessKey     string // ACL 凭证
+	SecretKey     string
+	// Pulsar，Topic 可以是完整名称或只写 topic 名
+	ServiceURL string // pulsar:// 或 pulsar+ssl://
+	AdminURL   string
+	Tenant     string
+	Namespace  string
+	Token      string // Pulsar/NATS token
+	// NATS，User/Password/Token 与 RabbitMQ、Pulsar 共用
+	Servers          []string // nats://host:4222，MQTT 使用 tcp://host:1883、ssl://host:8883
+	JetStream        bool     // 使用 JetStream 发布并通过 pull consumer 拉取，否则做 core NATS 请求/应答
+	Stream           string
+	Subject          string
+	StreamVerifyOnly bool // stream 不存在时只校验不创建
+	NKeySeedFile     string
+	CredsFile        string
+	// MQTT，Servers/User/Password 与 NATS 共用
+	MQTTVersion     string // 3.1, 3.1.1, 5
+	MQTTTopicPrefix string // 测试 topic 为 <prefix>/<run ID>
+	// ActiveMQ/Artemis 通过 STOMP 检测，Servers 为 host:port 列表
+	Destination string // 例: /queue/orders，为空时使用固定队列，指定 ManagementURL 时使用 /queue/precheck.<run ID> 并在检测结束后删除
 	// RabbitMQ
-	Host     string
-	Port     int
-	User     string
-	Password string
-	Vhost    string
+	Host          string
+	Port          int
+	Nodes         []string // 集群节点列表 host:port，逐个节点检查连接和跨节点收发
+	User          string
+	Password      string
+	Vhost         string
+	AuthMechanism string // plain, amqplain, external
+	// exchange 拓扑检查，Exchange 为空时使用默认 exchange 和固定队列
+	Exchange        string
+	ExchangeType    string // direct, topic, fanout, headers
+	ExchangePassive bool   // 只校验 exchange 是否存在，不创建
+	RoutingKey      string
+	BindingKey      string
+	QueueType       string // classic, quorum, stream
+	ManagementURL   string // RabbitMQ 为管理 API 地址(例: http://host:15672)，用于健康检查和策略查询；ActiveMQ 为 Jolokia 地址，用于删除临时队列
+	// 死信检测：临时队列的消息 TTL(毫秒)过期及 nack 后是否进入死信队列
+	DeadLetter    bool
+	DeadLetterTTL int
+	// TLS
+	TLS           bool
+	TLSCAFile     string
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSSkipVerify bool
+}
+
+type KafkaBrokerCheck struct {
+	ID           int      `json:"id"`
+	Advertised   string   `json:"advertised"`
+	Rack         string   `json:"rack,omitempty"`
+	Controller   bool     `json:"controller,omitempty"`
+	Resolved     []string `json:"resolved,omitempty"`
+	ResolveError string   `json:"resolve_error,omitempty"`
+	Reachable    bool     `json:"reachable"`
+	DialError    string   `json:"dial_error,omitempty"`
+	DialMs       float64  `json:"dial_ms,omitempty"`
+}
+
+type KafkaListenerResult struct {
+	Success   string             `json:"success"`
+	Error     string             `json:"error,omitempty"`
+	Bootstrap string             `json:"bootstrap,omitempty"`
+	Brokers   []KafkaBrokerCheck `json:"brokers,omitempty"`
+	Mismatch  []string           `json:"mismatch,omitempty"`
+}
+
+type KafkaReportBroker struct {
+	ID          int               `json:"id"`
+	Address     string            `json:"address"`
+	Rack        string            `json:"rack,omitempty"`
+	Controller  bool              `json:"controller,omitempty"`
+	ApiVersions map[string]string `json:"api_versions,omitempty"`
+	ApiError    string            `json:"api_error,omitempty"`
+}
+
+type KafkaReport struct {
+	Success         string              `json:"success"`
+	Error           string              `json:"error,omitempty"`
+	ClusterID       string              `json:"cluster_id,omitempty"`
+	Controller      int                 `json:"controller"`
+	Brokers         []KafkaReportBroker `json:"brokers"`
+	Topics          int                 `json:"topics"`
+	Partitions      int                 `json:"partitions"`
+	UnderReplicated []string            `json:"under_replicated"`
+	Offline         []string            `json:"offline"`
+	Topic           string              `json:"topic,omitempty"`
+	TopicConfig     map[string]string   `json:"topic_config,omitempty"`
+	TopicError      string              `json:"topic_error,omitempty"`
+}
+
+type KafkaGroupMember struct {
+	ID         string   `json:"id"`
+	ClientID   string   `json:"client_id"`
+	Host       string   `json:"host"`
+	Assignment []string `json:"assignment"`
+}
+
+type KafkaPartitionLag struct {
+	Topic     string `json:"topic"`
+	Partition int    `json:"partition"`
+	Committed int64  `json:"committed"` // -1 表示该分区没有提交过位点
+	End       int64  `json:"end"`
+	Lag       int64  `json:"lag"` // 未提交位点时为 -1，不计入总 lag
+	Member    string `json:"member,omitempty"`
+}
+
+type KafkaGroupLag struct {
+	Group      string              `json:"group"`
+	Success    string              `json:"success"`
+	Error      string              `json:"error,omitempty"`
+	State      string              `json:"state"`
+	Members    []KafkaGroupMember  `json:"members"`
+	Partitions []KafkaPartitionLag `json:"partitions"`
+	TotalLag   int64               `json:"total_lag"`
+}
+
+type KafkaLagResult struct {
+	Success   string          `json:"success"`
+	Error     string          `json:"error,omitempty"`
+	Threshold int64           `json:"threshold"`
+	Groups    []KafkaGroupLag `json:"groups"`
+}
+
+// 作用于检测队列的策略，Definition 为生效的策略定义
+type RabbitMQPolicyResult struct {
+	Success        string         `json:"success"`
+	Error          string         `json:"error,omitempty"`
+	Queue          string         `json:"queue"`
+	QueueType      string         `json:"queue_type,omitempty"`
+	Policy         string         `json:"policy,omitempty"`
+	OperatorPolicy string         `json:"operator_policy,omitempty"`
+	Arguments      map[string]any `json:"arguments,omitempty"`
+	Definition     map[string]any `json:"definition,omitempty"`
+	Warnings       []string       `json:"warnings,omitempty"`
+}
+
+type RabbitMQNodeHealth struct {
+	Name          string   `json:"name"`
+	Running       bool     `json:"running"`
+	Partitions    []string `json:"partitions,omitempty"`
+	FDUsed        int64    `json:"fd_used"`
+	FDTotal       int64    `json:"fd_total"`
+	MemUsed       int64    `json:"mem_used"`
+	MemLimit      int64    `json:"mem_limit"`
+	MemAlarm      bool     `json:"mem_alarm"`
+	DiskFree      int64    `json:"disk_free"`
+	DiskFreeLimit int64    `json:"disk_free_limit"`
+	DiskFreeAlarm bool     `json:"disk_free_alarm"`
+}
+
+// 用户在 vhost 上的权限正则，以及是否覆盖检测队列
+type RabbitMQPermissions struct {
+	Configure    string `json:"configure"`
+	Write        string `json:"write"`
+	Read         string `json:"read"`
+	CanConfigure bool   `json:"can_configure"`
+	CanWrite     bool   `json:"can_write"`
+	CanRead      bool   `json:"can_read"`
+}
+
+// 管理 API 健康检查结果，Problems 非空时判定失败
+type RabbitMQManagementResult struct {
+	Success         string               `json:"success"`
+	Error           string               `json:"error,omitempty"`
+	URL             string               `json:"url"`
+	ClusterName     string               `json:"cluster_name,omitempty"`
+	RabbitMQVersion string               `json:"rabbitmq_version,omitempty"`
+	ErlangVersion   string               `json:"erlang_version,omitempty"`
+	RunningNodes    int                  `json:"running_nodes"`
+	Nodes           []RabbitMQNodeHealth `json:"nodes,omitempty"`
+	Alarms          []string             `json:"alarms,omitempty"`
+	VhostExists     bool                 `json:"vhost_exists"`
+	Permissions     *RabbitMQPermissions `json:"permissions,omitempty"`
+	PermissionError string               `json:"permission_error,omitempty"`
+	Problems        []string             `json:"problems,omitempty"`
 }
 
 type MQResult struct {
-	Connect map[string]string `json:"connect"`
-	Write   map[string]string `json:"write"`
-	Delete  map[string]string `json:"delete"`
+	Listeners   *KafkaListenerResult         `json:"listeners,omitempty"`
+	Management  *RabbitMQManagementResult    `json:"management,omitempty"`
+	Nodes       map[string]map[string]string `json:"nodes,omitempty"`
+	CrossNode   map[string]map[string]string `json:"cross_node,omitempty"`
+	Connect     map[string]string            `json:"connect"`
+	QueuePolicy *RabbitMQPolicyResult        `json:"queue_policy,omitempty"`
+	Write       map[string]string            `json:"write"`
+	Guarantees  map[string]string            `json:"guarantees,omitempty"`
+	DeadLetter  map[string]string            `json:"dead_letter,omitempty"`
+	Delete      map[string]string            `json:"delete"`
+	MessageSize *MQMessageSizeResult         `json:"message_size,omitempty"`
+	Cleanup     map[string]string            `json:"cleanup,omitempty"`
+}
+
+// 一次指定大小的消息往返，失败时 Stage 为出错的阶段
+type MQMessageSizeAttempt struct {
+	Size        int     `json:"size"`
+	Success     string  `json:"success"`
+	RoundTripMs float64 `json:"round_trip_ms,omitempty"`
+	Stage       string  `json:"stage,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// 消息大小探测结果，MaxSucceeded 为往返成功的最大字节数，Error 为首次失败时的错误
+type MQMessageSizeResult struct {
+	Success      string                 `json:"success"`
+	Target       int                    `json:"target"`
+	MaxSucceeded int                    `json:"max_succeeded"`
+	FailedSize   int                    `json:"failed_size,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	Attempts     []MQMessageSizeAttempt `json:"attempts"`
+}
+
+type MQBenchConfig struct {
+	Duration    int // 压测时长(秒)
+	Producers   int // 并发生产者数
+	Consumers   int // 并发消费者数
+	MessageSize int // 消息体字节数
+	Count       int // 最多发送的消息数，0 表示只按时长限制
+}
+
+// Produce 为生产者每批确认耗时，EndToEnd 为消息从发送到被消费的延迟
+type MQBenchResult struct {
+	Connect  map[string]string `json:"connect"`
+	Bench    map[string]string `json:"bench"`
+	Produce  *LatencyStats     `json:"produce,omitempty"`
+	EndToEnd *LatencyStats     `json:"end_to_end,omitempty"`
+	Cleanup  map[string]string `json:"cleanup"`
 }